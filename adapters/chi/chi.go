@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+This package serves the eBay webhook endpoint from a chi router
+*/
+package chi
+
+import (
+	"net/http"
+
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	"github.com/go-chi/chi/v5"
+)
+
+//Handler returns a handler answering the challenge on GET and verifying and processing notifications on POST.
+//chi routes net/http handlers, so this is notification.Handler.
+//Input
+//	config - config details for processing
+//	opts - options overriding the defaults of the package level functions
+//Returns
+//	http handler
+func Handler(config *pojo.Config, opts ...sdk.Option) http.Handler {
+	return sdk.Handler(config, opts...)
+}
+
+//ClientHandler returns a handler serving the webhook endpoint with a client
+func ClientHandler(client *sdk.Client) http.Handler {
+	return client.Handler()
+}
+
+//Register mounts the webhook endpoint for GET and POST requests
+//Input
+//	router - chi router
+//	path - path of the endpoint, as registered with eBay
+//	handler - handler returned by Handler or ClientHandler
+func Register(router chi.Router, path string, handler http.Handler) {
+	router.Method(http.MethodGet, path, handler)
+	router.Method(http.MethodPost, path, handler)
+}
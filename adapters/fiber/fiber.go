@@ -0,0 +1,50 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+This package serves the eBay webhook endpoint from a fiber app
+*/
+package fiber
+
+import (
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+//Handler returns a fiber handler answering the challenge on GET and verifying and processing notifications on POST.
+//Fiber rejects bodies over its BodyLimit (4 MiB by default) before the handler runs.
+//Input
+//	config - config details for processing
+//	opts - options overriding the defaults of the package level functions
+//Returns
+//	fiber handler
+func Handler(config *pojo.Config, opts ...sdk.Option) fiber.Handler {
+	return adaptor.HTTPHandler(sdk.Handler(config, opts...))
+}
+
+//ClientHandler returns a fiber handler serving the webhook endpoint with a client
+func ClientHandler(client *sdk.Client) fiber.Handler {
+	return adaptor.HTTPHandler(client.Handler())
+}
+
+//Register mounts the webhook endpoint for GET and POST requests
+//Input
+//	router - fiber app or group
+//	path - path of the endpoint, as registered with eBay
+//	handler - handler returned by Handler or ClientHandler
+func Register(router fiber.Router, path string, handler fiber.Handler) {
+	router.Get(path, handler)
+	router.Post(path, handler)
+}
@@ -0,0 +1,40 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+This package serves the eBay webhook endpoint from Google Cloud Functions
+*/
+package gcf
+
+import (
+	"net/http"
+
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//Function returns an HTTP function answering the challenge on GET and verifying and processing notifications on POST.
+//Register it with the Functions Framework, e.g. functions.HTTP("webhook", gcf.Function(config))
+//Input
+//	config - config details for processing
+//	opts - options overriding the defaults of the package level functions
+//Returns
+//	HTTP function
+func Function(config *pojo.Config, opts ...sdk.Option) func(http.ResponseWriter, *http.Request) {
+	return sdk.Handler(config, opts...).ServeHTTP
+}
+
+//ClientFunction returns an HTTP function serving the webhook endpoint with a client
+func ClientFunction(client *sdk.Client) func(http.ResponseWriter, *http.Request) {
+	return client.Handler().ServeHTTP
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+This package serves the eBay webhook endpoint from AWS Lambda behind
+API Gateway (REST and HTTP APIs) or an Application Load Balancer
+*/
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//Handler translates Lambda proxy events into requests to the webhook handler of the SDK.
+//Its methods are passed to lambda.Start, e.g. lambda.Start(handler.APIGateway)
+type Handler struct {
+	handler http.Handler
+}
+
+//New is used to create a Lambda handler
+//Input
+//	config - config details for processing
+//	opts - options overriding the defaults of the package level functions
+//Returns
+//	lambda handler
+func New(config *pojo.Config, opts ...sdk.Option) *Handler {
+	return &Handler{handler: sdk.Handler(config, opts...)}
+}
+
+//NewClientHandler is used to create a Lambda handler serving the webhook endpoint with a client
+func NewClientHandler(client *sdk.Client) *Handler {
+	return &Handler{handler: client.Handler()}
+}
+
+//APIGateway handles API Gateway REST API proxy events
+func (h *Handler) APIGateway(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	query := url.Values(event.MultiValueQueryStringParameters)
+	if len(query) == 0 {
+		query = singleValues(event.QueryStringParameters)
+	}
+	var headers http.Header
+	if len(event.MultiValueHeaders) > 0 {
+		headers = multiValues(event.MultiValueHeaders)
+	} else {
+		headers = multiValues(singleValues(event.Headers))
+	}
+	status, header, body := h.serve(ctx, event.HTTPMethod, event.Path, query, headers, event.Body, event.IsBase64Encoded)
+	return events.APIGatewayProxyResponse{StatusCode: status, MultiValueHeaders: header, Body: body}, nil
+}
+
+//APIGatewayV2 handles API Gateway HTTP API payload format 2.0 events
+func (h *Handler) APIGatewayV2(ctx context.Context, event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	query, err := url.ParseQuery(event.RawQueryString)
+	if err != nil {
+		query = singleValues(event.QueryStringParameters)
+	}
+	// HTTP APIs join repeated headers with commas
+	status, header, body := h.serve(ctx, event.RequestContext.HTTP.Method, event.RawPath, query,
+		multiValues(singleValues(event.Headers)), event.Body, event.IsBase64Encoded)
+	return events.APIGatewayV2HTTPResponse{StatusCode: status, MultiValueHeaders: header, Body: body}, nil
+}
+
+//ALB handles Application Load Balancer target group events.
+//The response uses multi-value headers when they are enabled on the target group.
+func (h *Handler) ALB(ctx context.Context, event events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	multiValue := event.MultiValueHeaders != nil || event.MultiValueQueryStringParameters != nil
+	// the load balancer passes query parameters as received, without decoding them
+	query := url.Values{}
+	if multiValue {
+		for key, values := range event.MultiValueQueryStringParameters {
+			for _, value := range values {
+				query.Add(unescape(key), unescape(value))
+			}
+		}
+	} else {
+		for key, value := range event.QueryStringParameters {
+			query.Add(unescape(key), unescape(value))
+		}
+	}
+	headers := multiValues(event.MultiValueHeaders)
+	if !multiValue {
+		headers = multiValues(singleValues(event.Headers))
+	}
+
+	status, header, body := h.serve(ctx, event.HTTPMethod, event.Path, query, headers, event.Body, event.IsBase64Encoded)
+	response := events.ALBTargetGroupResponse{
+		StatusCode:        status,
+		StatusDescription: strconv.Itoa(status) + " " + http.StatusText(status),
+		Body:              body,
+	}
+	if multiValue {
+		response.MultiValueHeaders = header
+	} else {
+		response.Headers = make(map[string]string, len(header))
+		for key, values := range header {
+			response.Headers[key] = strings.Join(values, ", ")
+		}
+	}
+	return response, nil
+}
+
+//Serve an event with the webhook handler
+//Input
+//	ctx - context of the invocation
+//	method, path, query, header - request of the event
+//	body - body of the event
+//	encoded - whether the body is base64 encoded
+//Returns
+//	status code, headers and body of the response
+func (h *Handler) serve(ctx context.Context, method string, path string, query url.Values, header http.Header, body string, encoded bool) (int, map[string][]string, string) {
+	raw := []byte(body)
+	if encoded {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return http.StatusBadRequest, map[string][]string{"Content-Type": {"text/plain; charset=utf-8"}}, http.StatusText(http.StatusBadRequest) + "\n"
+		}
+		raw = decoded
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, (&url.URL{Path: path, RawQuery: query.Encode()}).RequestURI(), bytes.NewReader(raw))
+	if err != nil {
+		return http.StatusBadRequest, map[string][]string{"Content-Type": {"text/plain; charset=utf-8"}}, http.StatusText(http.StatusBadRequest) + "\n"
+	}
+	req.Header = header
+
+	w := &responseWriter{header: http.Header{}}
+	h.handler.ServeHTTP(w, req)
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.status, w.header, w.body.String()
+}
+
+//Convert single value parameters to multiple values
+func singleValues(values map[string]string) map[string][]string {
+	multi := make(map[string][]string, len(values))
+	for key, value := range values {
+		multi[key] = []string{value}
+	}
+	return multi
+}
+
+//Convert event headers, lower case for most integrations, to canonical header keys
+func multiValues(values map[string][]string) http.Header {
+	header := http.Header{}
+	for key, list := range values {
+		for _, value := range list {
+			header.Add(key, value)
+		}
+	}
+	return header
+}
+
+//Unescape a query parameter, keeping it as is when it is not escaped correctly
+func unescape(value string) string {
+	if unescaped, err := url.QueryUnescape(value); err == nil {
+		return unescaped
+	}
+	return value
+}
+
+//responseWriter buffers the response of the webhook handler
+type responseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(data)
+}
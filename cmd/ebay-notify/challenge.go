@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	helper "github.com/ebay/event-notification-golang-sdk.git/lib/helper"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	probe "github.com/ebay/event-notification-golang-sdk.git/lib/probe"
+)
+
+//Compute the challenge response of a code, or probe a deployed endpoint with a random code
+//Exits with 1 when the probed endpoint does not answer as eBay expects
+func challenge(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("challenge", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	code := flags.String("code", "", "challenge code sent by eBay")
+	token := flags.String("token", "", "verification token, overrides the config")
+	endpoint := flags.String("endpoint", "", "endpoint URL registered with eBay, overrides the config")
+	configPath := flags.String("config", "", "config.json holding the verificationToken and endpoint")
+	probeEndpoint := flags.Bool("probe", false, "call the endpoint with a random challenge code and check its response")
+	target := flags.String("url", "", "URL to probe when it differs from the endpoint, e.g. behind a proxy")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout of the probe")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s challenge (-token TOKEN -endpoint URL | -config FILE) (-code CODE | -probe [-url URL])\n\n", programName)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	config := &pojo.Config{}
+	if *configPath != "" {
+		loaded, err := loadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintln(stderr, "challenge:", err)
+			return exitUsage
+		}
+		config = loaded
+	}
+	if *token != "" {
+		config.VerificationToken = *token
+	}
+	if *endpoint != "" {
+		config.Endpoint = *endpoint
+	}
+	if config.VerificationToken == "" || config.Endpoint == "" {
+		fmt.Fprintln(stderr, "challenge: a verification token and an endpoint are required")
+		flags.Usage()
+		return exitUsage
+	}
+
+	if !*probeEndpoint {
+		if *code == "" {
+			fmt.Fprintln(stderr, "challenge: -code or -probe is required")
+			flags.Usage()
+			return exitUsage
+		}
+		fmt.Fprintln(stdout, helper.GenerateChallengeResponse(*code, config))
+		return exitOK
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	result, err := probe.Endpoint(ctx, &http.Client{}, config, *target)
+	if err != nil {
+		fmt.Fprintln(stderr, "challenge:", err)
+		return exitFailed
+	}
+	fmt.Fprintln(stdout, "GET", result.URL)
+	fmt.Fprintf(stdout, "status %d, content type %q\n", result.StatusCode, result.ContentType)
+	fmt.Fprintln(stdout, "expected challengeResponse", result.Expected)
+	fmt.Fprintln(stdout, "received challengeResponse", result.Received)
+	fmt.Fprintln(stdout)
+	if result.OK() {
+		fmt.Fprintln(stdout, "The endpoint answers the challenge as eBay expects")
+		return exitOK
+	}
+	for _, problem := range result.Problems {
+		fmt.Fprintln(stdout, "problem:", problem)
+	}
+	return exitFailed
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	helper "github.com/ebay/event-notification-golang-sdk.git/lib/helper"
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	"github.com/ebay/event-notification-golang-sdk.git/lib/notification/notificationtest"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+const (
+	testKid      = "9936261a-7d7b-4621-a0f1-96ccb428af49"
+	testToken    = "71745723-d031-455c-bfa5-f90d11b4f20a"
+	testEndpoint = "https://example.com/webhook"
+)
+
+func writeFile(t *testing.T, dir string, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	key := notificationtest.GenerateKey(t, testKid)
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemPath := writeFile(t, dir, "key.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	dump, _ := json.Marshal(map[string]pojo.Response{testKid: {
+		Key: constants.KeyStart + base64.StdEncoding.EncodeToString(der) + constants.KeyEnd, Algorithm: "ECDSA", Digest: "SHA1",
+	}})
+	cachePath := writeFile(t, dir, "cache.json", dump)
+	body, signature := key.SignMessage(t, notificationtest.NewMessage("ITEM_SOLD", "n-1"))
+	bodyPath := writeFile(t, dir, "body.json", body)
+	config, _ := json.Marshal(pojo.Config{Endpoint: testEndpoint, VerificationToken: testToken})
+	configPath := writeFile(t, dir, "config.json", config)
+
+	server := httptest.NewServer(sdk.Handler(&pojo.Config{Endpoint: testEndpoint, VerificationToken: testToken}))
+	defer server.Close()
+	misconfigured := httptest.NewServer(sdk.Handler(&pojo.Config{Endpoint: testEndpoint + "/", VerificationToken: testToken}))
+	defer misconfigured.Close()
+
+	for name, test := range map[string]struct {
+		args   []string
+		stdin  string
+		exit   int
+		stdout string
+		stderr string
+	}{
+		"no command":      {nil, "", exitUsage, "", "Usage: ebay-notify <command>"},
+		"help":            {[]string{"help"}, "", exitUsage, "", "Commands:"},
+		"unknown command": {[]string{"sign"}, "", exitUsage, "", `unknown command "sign"`},
+		"unknown flag":    {[]string{"challenge", "-nope"}, "", exitUsage, "", "flag provided but not defined"},
+
+		"challenge code": {[]string{"challenge", "-token", testToken, "-endpoint", testEndpoint, "-code", "abc"}, "", exitOK,
+			helper.GenerateChallengeResponse("abc", &pojo.Config{Endpoint: testEndpoint, VerificationToken: testToken}), ""},
+		"challenge config": {[]string{"challenge", "-config", configPath, "-code", "abc"}, "", exitOK,
+			helper.GenerateChallengeResponse("abc", &pojo.Config{Endpoint: testEndpoint, VerificationToken: testToken}), ""},
+		"challenge without token": {[]string{"challenge", "-endpoint", testEndpoint, "-code", "abc"}, "", exitUsage, "", "a verification token and an endpoint are required"},
+		"challenge without code":  {[]string{"challenge", "-config", configPath}, "", exitUsage, "", "-code or -probe is required"},
+		"challenge missing config": {[]string{"challenge", "-config", filepath.Join(dir, "missing.json"), "-code", "abc"}, "", exitUsage, "",
+			"missing.json"},
+		"probe": {[]string{"challenge", "-config", configPath, "-probe", "-url", server.URL + "/webhook"}, "", exitOK,
+			"The endpoint answers the challenge as eBay expects", ""},
+		"probe mismatch": {[]string{"challenge", "-config", configPath, "-probe", "-url", misconfigured.URL + "/webhook"}, "", exitFailed,
+			"problem: challengeResponse was computed with endpoint", ""},
+
+		"verify pem":   {[]string{"verify", "-body", bodyPath, "-signature", signature, "-pem", pemPath}, "", exitOK, "ValidateRawSignature: " + constants.Success, ""},
+		"verify cache": {[]string{"verify", "-body", bodyPath, "-signature", signature, "-cache", cachePath}, "", exitOK, "cache dump", ""},
+		"verify stdin": {[]string{"verify", "-body", "-", "-signature", signature, "-pem", pemPath}, string(body), exitOK, "ValidateRawSignature: " + constants.Success, ""},
+		"verify tampered": {[]string{"verify", "-body", "-", "-signature", signature, "-pem", pemPath}, strings.Replace(string(body), "n-1", "n-2", 1), exitFailed,
+			"ValidateRawSignature: " + constants.Error, ""},
+		"verify without signature": {[]string{"verify", "-body", bodyPath, "-pem", pemPath}, "", exitUsage, "", "-body and -signature are required"},
+		"verify two key sources": {[]string{"verify", "-body", bodyPath, "-signature", signature, "-pem", pemPath, "-cache", cachePath}, "", exitUsage, "",
+			"exactly one of -pem, -cache or -config is required"},
+		"verify without credentials": {[]string{"verify", "-body", bodyPath, "-signature", signature, "-config", configPath}, "", exitUsage, "",
+			"missing client credentials for PRODUCTION"},
+	} {
+		var stdout, stderr bytes.Buffer
+		exit := run(test.args, strings.NewReader(test.stdin), &stdout, &stderr)
+		if exit != test.exit {
+			t.Errorf("%s: expected exit code %d, got %d\nstdout: %s\nstderr: %s", name, test.exit, exit, stdout.String(), stderr.String())
+		}
+		if !strings.Contains(stdout.String(), test.stdout) {
+			t.Errorf("%s: expected stdout to contain %q, got %q", name, test.stdout, stdout.String())
+		}
+		if !strings.Contains(stderr.String(), test.stderr) {
+			t.Errorf("%s: expected stderr to contain %q, got %q", name, test.stderr, stderr.String())
+		}
+	}
+}
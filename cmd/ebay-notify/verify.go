@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	helper "github.com/ebay/event-notification-golang-sdk.git/lib/helper"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	service "github.com/ebay/event-notification-golang-sdk.git/lib/service"
+)
+
+//Verify the signature of a notification step by step
+//Exits with 1 when ValidateRawSignature would reject the notification
+func verify(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	bodyPath := flags.String("body", "", "file holding the raw request body, - for standard input")
+	signature := flags.String("signature", "", "value of the X-EBAY-SIGNATURE header")
+	pemPath := flags.String("pem", "", "PEM file of the public key")
+	cachePath := flags.String("cache", "", "key cache dump, a JSON object of notification API responses by key id")
+	configPath := flags.String("config", "", "config.json holding the client credentials, to fetch the key from the notification API")
+	environment := flags.String("env", constants.EnvironmentProduction, "environment of the notification API, PRODUCTION or SANDBOX")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout of the notification API calls")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s verify -body FILE -signature VALUE (-pem FILE | -cache FILE | -config FILE [-env ENV])\n\n", programName)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *bodyPath == "" || *signature == "" {
+		fmt.Fprintln(stderr, "verify: -body and -signature are required")
+		flags.Usage()
+		return exitUsage
+	}
+
+	body, err := readInput(*bodyPath, stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, "verify:", err)
+		return exitUsage
+	}
+	keys, source, err := loadKeys(*pemPath, *cachePath, *configPath, *environment, *timeout)
+	if err != nil {
+		fmt.Fprintln(stderr, "verify:", err)
+		return exitUsage
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	diagnosis := helper.Diagnose(ctx, body, strings.TrimSpace(*signature), keys)
+
+	fmt.Fprintf(stdout, "Public key from %s\n\n", source)
+	for _, step := range diagnosis.Steps {
+		status := "ok"
+		if !step.OK {
+			status = "FAIL"
+		}
+		fmt.Fprintf(stdout, "[%-4s] %-28s %s\n", status, step.Name, step.Detail)
+	}
+	if len(diagnosis.Hints) > 0 {
+		fmt.Fprintln(stdout)
+		for _, hint := range diagnosis.Hints {
+			fmt.Fprintln(stdout, "hint:", hint)
+		}
+	}
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, "ValidateRawSignature:", outcome(diagnosis.Err))
+	fmt.Fprintln(stdout, "ValidateSignature:   ", outcome(diagnosis.MessageErr))
+	if diagnosis.Err != nil {
+		return exitFailed
+	}
+	return exitOK
+}
+
+//Returns the string returned by the validate functions for a verification error
+func outcome(err error) string {
+	if err != nil {
+		return constants.Error
+	}
+	return constants.Success
+}
+
+//Read a file, or the standard input for -
+func readInput(path string, stdin io.Reader) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
+//Create the key provider selected by the flags
+//Returns
+//	key provider
+//	description of the key source
+//	error
+func loadKeys(pemPath, cachePath, configPath, environment string, timeout time.Duration) (service.KeyProvider, string, error) {
+	selected := 0
+	for _, path := range []string{pemPath, cachePath, configPath} {
+		if path != "" {
+			selected++
+		}
+	}
+	if selected != 1 {
+		return nil, "", errors.New("exactly one of -pem, -cache or -config is required")
+	}
+
+	switch {
+	case pemPath != "":
+		data, err := ioutil.ReadFile(pemPath)
+		if err != nil {
+			return nil, "", err
+		}
+		key, err := service.ParsePublicKey(string(data))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", pemPath, err)
+		}
+		return anyKeyID{key}, "PEM file " + pemPath, nil
+
+	case cachePath != "":
+		data, err := ioutil.ReadFile(cachePath)
+		if err != nil {
+			return nil, "", err
+		}
+		var dump service.KeyDump
+		if err := json.Unmarshal(data, &dump); err != nil {
+			return nil, "", fmt.Errorf("%s: %w", cachePath, err)
+		}
+		return dump, fmt.Sprintf("cache dump %s (%d keys)", cachePath, len(dump)), nil
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return nil, "", err
+	}
+	env := config.Production
+	switch environment {
+	case constants.EnvironmentProduction:
+	case constants.EnvironmentSandbox:
+		env = config.Sandbox
+	default:
+		return nil, "", fmt.Errorf("unknown environment %q", environment)
+	}
+	if env.ClientID == "" || env.ClientSecret == "" {
+		return nil, "", fmt.Errorf("%s: missing client credentials for %s", configPath, environment)
+	}
+	custom := &pojo.CustomEnvironment{
+		BaseURL:      env.BaseURL,
+		RedirectURI:  env.RedirectURI,
+		ClientID:     env.ClientID,
+		ClientSecret: env.ClientSecret,
+		DevID:        env.DevID,
+		Environment:  environment,
+	}
+	return service.NewRemoteKeyProvider(custom, &http.Client{Timeout: timeout}), environment + " notification API", nil
+}
+
+//anyKeyID serves the key of a PEM file whatever the key id of the header
+type anyKeyID struct {
+	key crypto.PublicKey
+}
+
+func (a anyKeyID) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	return a.key, nil
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+fake-ebay serves fake eBay identity and public key APIs, and publishes signed notifications
+*/
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	fakeebay "github.com/ebay/event-notification-golang-sdk.git/lib/fakeebay"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+const programName = "fake-ebay"
+
+const usage = `Usage: fake-ebay <command> [flags]
+
+Commands:
+  serve      serve the identity and public key APIs
+  publish    sign a notification and POST it to an endpoint
+
+Run 'fake-ebay <command> -h' for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "serve":
+		err = serve(os.Args[2:], os.Stdout)
+	case "publish":
+		err = publish(os.Args[2:], os.Stdout)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", programName, os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+//Serve the fake APIs
+func serve(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	keyPath := flags.String("key", "", "private key file, generated when missing, shared with publish")
+	pemDir := flags.String("pem-dir", "", "directory to write the public key to as <kid>.pem, for service.NewPEMDirKeyProvider")
+	clientID := flags.String("client-id", fakeebay.DefaultClientID, "accepted client id")
+	clientSecret := flags.String("client-secret", fakeebay.DefaultClientSecret, "accepted client secret")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	key, err := loadOrGenerateKey(*keyPath)
+	if err != nil {
+		return err
+	}
+	server, err := fakeebay.NewServer(fakeebay.WithKey(key), fakeebay.WithCredentials(*clientID, *clientSecret))
+	if err != nil {
+		return err
+	}
+	if *pemDir != "" {
+		publicKey, err := key.PublicKeyPEM()
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(*pemDir, key.ID+".pem"), []byte(publicKey), 0644); err != nil {
+			return err
+		}
+	}
+
+	baseURL := "http://" + *addr
+	config, _ := json.MarshalIndent(server.Config(baseURL), "", "  ")
+	fmt.Fprintf(stdout, "Fake eBay APIs listening on %s\n\nkey id: %s\npublic key API: %s%s\nconfig.json:\n%s\n",
+		baseURL, key.ID, baseURL, fakeebay.PublicKeyPath, config)
+	return http.ListenAndServe(*addr, server)
+}
+
+//Publish a notification
+func publish(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("publish", flag.ContinueOnError)
+	keyPath := flags.String("key", "", "private key file written by serve")
+	target := flags.String("target", "", "URL of the endpoint")
+	file := flags.String("file", "", "notification JSON, a new notification of -topic when empty")
+	topic := flags.String("topic", "MARKETPLACE_ACCOUNT_DELETION", "topic of a new notification")
+	raw := flags.Bool("raw", false, "send -file as is, without updating publishDate and publishAttemptCount")
+	attempts := flags.Int("attempts", fakeebay.DefaultAttempts, "number of deliveries before giving up")
+	backoff := flags.Duration("backoff", fakeebay.DefaultBackoff, "delay before the first redelivery, doubled for each redelivery")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *keyPath == "" || *target == "" {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+
+	data, err := ioutil.ReadFile(*keyPath)
+	if err != nil {
+		return err
+	}
+	key, err := fakeebay.ParseKey(data)
+	if err != nil {
+		return err
+	}
+	publisher := fakeebay.NewPublisher(key, fakeebay.WithAttempts(*attempts), fakeebay.WithBackoff(*backoff),
+		fakeebay.WithHTTPClient(&http.Client{Timeout: 30 * time.Second}))
+
+	var delivery *fakeebay.Delivery
+	var publishErr error
+	switch {
+	case *file != "" && *raw:
+		body, err := ioutil.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		delivery, publishErr = publisher.PublishRaw(context.Background(), *target, body)
+	default:
+		message, err := fakeebay.NewMessage(*topic)
+		if err != nil {
+			return err
+		}
+		if *file != "" {
+			body, err := ioutil.ReadFile(*file)
+			if err != nil {
+				return err
+			}
+			message = &pojo.Message{}
+			if err := json.Unmarshal(body, message); err != nil {
+				return fmt.Errorf("%s: %w", *file, err)
+			}
+		}
+		delivery, publishErr = publisher.Publish(context.Background(), *target, message)
+	}
+
+	for _, attempt := range delivery.Attempts {
+		if attempt.Err != nil {
+			fmt.Fprintf(stdout, "attempt %d at %s: %v\n", attempt.Number, attempt.SentAt.Format(time.RFC3339), attempt.Err)
+		} else {
+			fmt.Fprintf(stdout, "attempt %d at %s: %d %s\n", attempt.Number, attempt.SentAt.Format(time.RFC3339), attempt.StatusCode, http.StatusText(attempt.StatusCode))
+		}
+	}
+	return publishErr
+}
+
+//Read the private key file, or generate and write it when missing
+func loadOrGenerateKey(path string) (*fakeebay.Key, error) {
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err == nil {
+			return fakeebay.ParseKey(data)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	key, err := fakeebay.GenerateKey("")
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return key, nil
+	}
+	data, err := key.MarshalPEM()
+	if err != nil {
+		return nil, err
+	}
+	return key, ioutil.WriteFile(path, data, 0600)
+}
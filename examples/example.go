@@ -23,54 +23,9 @@ import (
 	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
 	"github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
 	"io/ioutil"
-	"net/http"
 	"strings"
 )
 
-//Process Notification request
-//This function handles processing message notification resquest and respond accordingly
-//Input
-//	gin.Context - Request/Response context
-func processNotification(c *gin.Context) {
-	var body pojo.Message
-	env := "PRODUCTION"
-	json.NewDecoder(c.Request.Body).Decode(&body)
-	signature := c.Request.Header[constants.XEbaySignature][0]
-	err, responseCode := sdk.ValidateAndProcess(&body, signature, Config, env)
-	if strings.EqualFold(err, constants.HTTPStatusCodePreconditionFailed) {
-		fmt.Println(`Signature validation failed`)
-		c.JSON(http.StatusInternalServerError, "Signature validation processing failure")
-	} else if !strings.EqualFold(err, "") {
-		fmt.Println(`Something went wrong`)
-		c.JSON(http.StatusInternalServerError, "Internal Server Error")
-	} else {
-		fmt.Println(responseCode)
-		c.JSON(http.StatusOK, "Successful")
-	}
-}
-
-//Get challenge code
-//This function handles in get challenge code request and respond accordingly
-//Input
-//	gin.Context - Request/Response context
-func getChallengeCode(c *gin.Context) {
-	challengeCode := c.Query("challenge_code")
-	if !strings.EqualFold(challengeCode, "") {
-		// challengeResponse := challengeCode
-		err, challengeResponse := sdk.ValidateEndpoint(challengeCode, Config)
-		if !strings.EqualFold(err, "") {
-			c.JSON(http.StatusInternalServerError, err)
-		}
-		c.JSON(http.StatusOK, gin.H{
-			"challengeResponse": challengeResponse,
-		})
-
-	} else {
-		c.JSON(http.StatusInternalServerError, "Missing 'challenge_code' request param")
-	}
-
-}
-
 //To load config data
 //This functions takes in config object and load data from config.json file.
 //Input
@@ -101,9 +56,12 @@ func main() {
 		panic("Failed to load config file")
 	}
 
+	// the SDK handler answers the challenge on GET and verifies notifications on POST
+	webhook := gin.WrapH(sdk.Handler(Config, sdk.WithEnvironment(constants.EnvironmentProduction)))
+
 	router := gin.Default()
-	router.POST("/webhook", processNotification)
-	router.GET("/webhook", getChallengeCode)
+	router.POST("/webhook", webhook)
+	router.GET("/webhook", webhook)
 
 	router.Run("localhost:8080")
 }
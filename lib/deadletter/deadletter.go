@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+This package parks notifications whose processor kept failing, for inspection and redrive
+*/
+package deadletter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"time"
+
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//ErrNotFound is returned when no dead letter has the requested id
+var ErrNotFound = errors.New("dead letter not found")
+
+//Attempt is one execution of the processor
+type Attempt struct {
+	Number    int       `json:"number"`
+	StartedAt time.Time `json:"startedAt"`
+	Error     string    `json:"error"`
+}
+
+//Letter is a notification whose processing failed and its attempt history
+type Letter struct {
+	ID             string        `json:"id"`
+	Topic          string        `json:"topic"`
+	NotificationID string        `json:"notificationId"`
+	Message        *pojo.Message `json:"message"`
+	//Error is the error of the last attempt
+	Error      string    `json:"error"`
+	Attempts   []Attempt `json:"attempts"`
+	ReceivedAt time.Time `json:"receivedAt"`
+	DeadAt     time.Time `json:"deadAt"`
+}
+
+//Store keeps dead letters until they are redriven or discarded.
+//Implementations must be safe for concurrent use.
+type Store interface {
+	//Put adds or replaces a dead letter
+	Put(ctx context.Context, letter *Letter) error
+	//Get returns a dead letter, or an error matching ErrNotFound
+	Get(ctx context.Context, id string) (*Letter, error)
+	//List returns the dead letters, oldest first
+	List(ctx context.Context) ([]*Letter, error)
+	//Delete removes a dead letter
+	Delete(ctx context.Context, id string) error
+}
+
+//NewID is used to generate a random dead letter id
+func NewID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+//Sort letters oldest first
+func sortLetters(letters []*Letter) {
+	sort.SliceStable(letters, func(i, j int) bool {
+		return letters[i].DeadAt.Before(letters[j].DeadAt)
+	})
+}
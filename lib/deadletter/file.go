@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package deadletter
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//FileStore keeps one JSON file per dead letter in a directory, so they can also be inspected with standard tools
+type FileStore struct {
+	dir string
+}
+
+//NewFileStore is used to create a store in a directory
+//Input
+//	dir - directory of the dead letter files, created when missing
+//Returns
+//	file store
+//	error when the directory cannot be created
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+//Put adds or replaces a dead letter
+func (s *FileStore) Put(ctx context.Context, letter *Letter) error {
+	path, err := s.path(letter.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(letter, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(s.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+	}
+	return err
+}
+
+//Get returns a dead letter, or an error matching ErrNotFound
+func (s *FileStore) Get(ctx context.Context, id string) (*Letter, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.read(path)
+}
+
+//List returns the dead letters, oldest first
+func (s *FileStore) List(ctx context.Context) ([]*Letter, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	letters := make([]*Letter, 0, len(paths))
+	for _, path := range paths {
+		letter, err := s.read(path)
+		if errors.Is(err, ErrNotFound) {
+			// deleted in between
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+	sortLetters(letters)
+	return letters, nil
+}
+
+//Delete removes a dead letter
+func (s *FileStore) Delete(ctx context.Context, id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+//Returns the file of a dead letter, ids generated by NewID are hexadecimal
+func (s *FileStore) path(id string) (string, error) {
+	if _, err := hex.DecodeString(id); err != nil || id == "" {
+		return "", fmt.Errorf("%w: invalid id %q", ErrNotFound, id)
+	}
+	return filepath.Join(s.dir, strings.ToLower(id)+".json"), nil
+}
+
+//Read a dead letter file
+func (s *FileStore) read(path string) (*Letter, error) {
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	var letter Letter
+	if err := json.Unmarshal(data, &letter); err != nil {
+		return nil, err
+	}
+	return &letter, nil
+}
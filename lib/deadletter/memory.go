@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+//MemoryStore keeps dead letters in memory, they are lost on restart.
+//Letters are copied on the way in and out, so callers may modify the letters they hold.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	letters map[string]*Letter
+}
+
+//NewMemoryStore is used to create an in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{letters: make(map[string]*Letter)}
+}
+
+//Put adds or replaces a dead letter
+func (s *MemoryStore) Put(ctx context.Context, letter *Letter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.letters[letter.ID] = copyLetter(letter)
+	return nil
+}
+
+//Get returns a dead letter, or an error matching ErrNotFound
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Letter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	letter, ok := s.letters[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyLetter(letter), nil
+}
+
+//List returns the dead letters, oldest first
+func (s *MemoryStore) List(ctx context.Context) ([]*Letter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	letters := make([]*Letter, 0, len(s.letters))
+	for _, letter := range s.letters {
+		letters = append(letters, copyLetter(letter))
+	}
+	sortLetters(letters)
+	return letters, nil
+}
+
+//Delete removes a dead letter
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.letters, id)
+	return nil
+}
+
+//Returns a copy of a letter sharing no memory with it
+func copyLetter(letter *Letter) *Letter {
+	copied := *letter
+	copied.Attempts = append([]Attempt(nil), letter.Attempts...)
+	if letter.Message != nil {
+		message := *letter.Message
+		message.Notification.Data = append(json.RawMessage(nil), letter.Message.Notification.Data...)
+		copied.Message = &message
+	}
+	return &copied
+}
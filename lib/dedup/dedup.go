@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+This package deduplicates notifications redelivered by eBay, keyed on their notificationId
+*/
+package dedup
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	//DefaultRetention is how long a processed notification id is remembered
+	DefaultRetention = 24 * time.Hour
+	//DefaultLockTimeout is how long a notification id stays locked while it is processed,
+	//after which a crashed delivery no longer blocks retries
+	DefaultLockTimeout = 5 * time.Minute
+)
+
+//State of a notification id in a store
+type State int
+
+const (
+	//Acquired means the caller locked the notification id and must Complete or Release it with the lock token
+	Acquired State = iota
+	//InProgress means another delivery of the notification is being processed
+	InProgress
+	//Done means the notification was processed within the retention window
+	Done
+)
+
+func (s State) String() string {
+	switch s {
+	case Acquired:
+		return "acquired"
+	case InProgress:
+		return "in-progress"
+	case Done:
+		return "done"
+	}
+	return "unknown"
+}
+
+//Store records which notifications are being or have been processed.
+//Locks are owned by the token returned by Acquire: once a lock expires and another delivery takes it over,
+//Complete and Release with the stale token leave the new lock alone.
+//Implementations must be safe for concurrent use.
+type Store interface {
+	//Acquire locks a notification id for lockTimeout unless it is locked or done.
+	//The token identifies the lock, it is only returned with Acquired.
+	Acquire(ctx context.Context, id string, lockTimeout time.Duration) (State, string, error)
+	//Complete marks a notification id locked with token as done for the retention window
+	Complete(ctx context.Context, id string, token string, retention time.Duration) error
+	//Release unlocks a notification id locked with token whose processing failed, so a retry can process it
+	Release(ctx context.Context, id string, token string) error
+}
+
+//Returns a random lock token
+func newToken() (string, error) {
+	var token [16]byte
+	if _, err := rand.Read(token[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token[:]), nil
+}
+
+//options shared by the stores
+type options struct {
+	now         func() time.Time
+	table       string
+	placeholder func(n int) string
+	prefix      string
+}
+
+//Option configures a store
+type Option func(*options)
+
+//WithClock sets the function returning the current time. Default: time.Now
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+//Returns the options of a store
+func newOptions(opts []Option) *options {
+	o := &options{
+		now:         time.Now,
+		table:       DefaultTable,
+		placeholder: QuestionPlaceholder,
+		prefix:      DefaultKeyPrefix,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
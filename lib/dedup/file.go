@@ -0,0 +1,247 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package dedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+//lockName is the file locked by the instances sharing a directory while they change it
+const lockName = ".lock"
+
+//FileStore keeps one file per notification id in a directory.
+//Changes are made under a lock on a file of the directory, so instances sharing it do not process
+//the same notification twice, and files are written to a temporary file first so they are never read half written.
+type FileStore struct {
+	dir string
+	now func() time.Time
+	mu  sync.Mutex
+}
+
+//fileEntry is the content of a notification id file
+type fileEntry struct {
+	ID      string    `json:"id"`
+	State   State     `json:"state"`
+	Token   string    `json:"token,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+//NewFileStore is used to create a store in a directory
+//Input
+//	dir - directory of the notification id files, created when missing
+//	opts - options, WithClock
+//Returns
+//	file store
+//	error when the directory cannot be created
+func NewFileStore(dir string, opts ...Option) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir, now: newOptions(opts).now}, nil
+}
+
+//Acquire locks a notification id for lockTimeout unless it is locked or done
+func (s *FileStore) Acquire(ctx context.Context, id string, lockTimeout time.Duration) (State, string, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, "", err
+	}
+	defer unlock()
+
+	now := s.now()
+	path := s.path(id)
+	entry, err := s.read(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, "", err
+	}
+	if err == nil && now.Before(entry.Expires) {
+		return entry.State, "", nil
+	}
+	// missing, or expired and taken over
+	token, err := newToken()
+	if err != nil {
+		return 0, "", err
+	}
+	err = s.write(path, fileEntry{ID: id, State: InProgress, Token: token, Expires: now.Add(lockTimeout)}, entry != nil)
+	if errors.Is(err, os.ErrExist) {
+		return InProgress, "", nil
+	} else if err != nil {
+		return 0, "", err
+	}
+	return Acquired, token, nil
+}
+
+//Complete marks a notification id locked with token as done for the retention window,
+//unless another delivery took the lock over or completed it
+func (s *FileStore) Complete(ctx context.Context, id string, token string, retention time.Duration) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	now := s.now()
+	path := s.path(id)
+	entry, err := s.read(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil && now.Before(entry.Expires) && !entry.lockedBy(token) {
+		return nil
+	}
+	return s.write(path, fileEntry{ID: id, State: Done, Expires: now.Add(retention)}, true)
+}
+
+//Release unlocks a notification id locked with token whose processing failed
+func (s *FileStore) Release(ctx context.Context, id string, token string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path := s.path(id)
+	entry, err := s.read(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !entry.lockedBy(token) {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+//Purge is used to remove the files of expired notification ids
+func (s *FileStore) Purge(ctx context.Context) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		entry, err := s.read(path)
+		if err == nil && now.Before(entry.Expires) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+//Locks the directory against the other instances sharing it
+//Returns
+//	function releasing the lock
+//	error when the lock file cannot be opened or locked
+func (s *FileStore) lock() (func(), error) {
+	s.mu.Lock()
+	file, err := os.OpenFile(filepath.Join(s.dir, lockName), os.O_RDWR|os.O_CREATE, 0600)
+	if err == nil {
+		if err = lockFile(file); err != nil {
+			file.Close()
+		}
+	}
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	return func() {
+		unlockFile(file)
+		file.Close()
+		s.mu.Unlock()
+	}, nil
+}
+
+//Write a notification id file through a temporary file. A new file is linked into place, so it is
+//never created twice, an existing one is replaced by renaming.
+//Returns
+//	error matching os.ErrExist when a new file already exists
+func (s *FileStore) write(path string, entry fileEntry, replace bool) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(s.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if replace {
+		return os.Rename(temp.Name(), path)
+	}
+	return os.Link(temp.Name(), path)
+}
+
+//Returns the file of a notification id, hashed as ids are chosen by the sender
+func (s *FileStore) path(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+//Read a notification id file
+func (s *FileStore) read(path string) (*fileEntry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry fileEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		// a damaged file is treated as locked until it expires
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		return &fileEntry{State: InProgress, Expires: info.ModTime().Add(DefaultLockTimeout)}, nil
+	}
+	return &entry, nil
+}
+
+//Returns whether the entry is the lock identified by token
+func (e *fileEntry) lockedBy(token string) bool {
+	return e.State == InProgress && e.Token == token
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package dedup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//sweepInterval is the number of acquisitions between removals of expired entries
+const sweepInterval = 1024
+
+//MemoryStore keeps notification ids in memory, for a single instance
+type MemoryStore struct {
+	mu       sync.Mutex
+	entries  map[string]memoryEntry
+	now      func() time.Time
+	acquired int
+}
+
+//memoryEntry is the state of a notification id, the token of its lock and its expiry
+type memoryEntry struct {
+	state   State
+	token   string
+	expires time.Time
+}
+
+//NewMemoryStore is used to create an in-memory store
+//Input
+//	opts - options, WithClock
+//Returns
+//	memory store
+func NewMemoryStore(opts ...Option) *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry), now: newOptions(opts).now}
+}
+
+//Acquire locks a notification id for lockTimeout unless it is locked or done
+func (s *MemoryStore) Acquire(ctx context.Context, id string, lockTimeout time.Duration) (State, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.acquired++
+	if s.acquired%sweepInterval == 0 {
+		for key, entry := range s.entries {
+			if !now.Before(entry.expires) {
+				delete(s.entries, key)
+			}
+		}
+	}
+
+	if entry, ok := s.entries[id]; ok && now.Before(entry.expires) {
+		return entry.state, "", nil
+	}
+	token, err := newToken()
+	if err != nil {
+		return 0, "", err
+	}
+	s.entries[id] = memoryEntry{state: InProgress, token: token, expires: now.Add(lockTimeout)}
+	return Acquired, token, nil
+}
+
+//Complete marks a notification id locked with token as done for the retention window,
+//unless another delivery took the lock over or completed it
+func (s *MemoryStore) Complete(ctx context.Context, id string, token string, retention time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if entry, ok := s.entries[id]; ok && now.Before(entry.expires) && !entry.lockedBy(token) {
+		return nil
+	}
+	s.entries[id] = memoryEntry{state: Done, expires: now.Add(retention)}
+	return nil
+}
+
+//Release unlocks a notification id locked with token whose processing failed
+func (s *MemoryStore) Release(ctx context.Context, id string, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[id]; ok && entry.lockedBy(token) {
+		delete(s.entries, id)
+	}
+	return nil
+}
+
+//Returns whether the entry is the lock identified by token
+func (e memoryEntry) lockedBy(token string) bool {
+	return e.state == InProgress && e.token == token
+}
+
+//Len returns the number of notification ids in the store, including expired ones
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
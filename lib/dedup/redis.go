@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package dedup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//DefaultKeyPrefix is prepended to notification ids in the Redis store
+const DefaultKeyPrefix = "ebay:notification:"
+
+//Values of the notification id keys, a lock is followed by its token
+const (
+	redisInProgress = "in-progress"
+	redisDone       = "done"
+)
+
+//redisCompareAndDelete deletes a key only while it holds the given value, in a single step
+const redisCompareAndDelete = `if redis.call('get', KEYS[1]) == ARGV[1] then return redis.call('del', KEYS[1]) end return 0`
+
+//redisCompareAndSet sets a key with an expiry only while it holds the given value or is missing, in a single step
+const redisCompareAndSet = `local value = redis.call('get', KEYS[1]) ` +
+	`if value == ARGV[1] or not value then return redis.call('set', KEYS[1], ARGV[2], 'PX', ARGV[3]) end return 0`
+
+//RedisDoFunc sends a command to a Redis compatible server and returns its reply. The server must
+//support EVAL, used to complete and release a lock atomically.
+//Nil replies must be returned as a nil value without error, e.g. for go-redis:
+//
+//	func(ctx context.Context, args ...interface{}) (interface{}, error) {
+//		reply, err := rdb.Do(ctx, args...).Result()
+//		if err == redis.Nil {
+//			return nil, nil
+//		}
+//		return reply, err
+//	}
+type RedisDoFunc func(ctx context.Context, args ...interface{}) (interface{}, error)
+
+//WithKeyPrefix sets the prefix of the keys of the Redis store. Default: DefaultKeyPrefix
+func WithKeyPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+//RedisStore keeps notification ids in a Redis compatible server shared by all instances.
+//Keys expire with the lock timeout or retention window.
+type RedisStore struct {
+	do     RedisDoFunc
+	prefix string
+}
+
+//NewRedisStore is used to create a store on a Redis compatible server
+//Input
+//	do - function sending commands to the server
+//	opts - options, WithKeyPrefix
+//Returns
+//	redis store
+func NewRedisStore(do RedisDoFunc, opts ...Option) *RedisStore {
+	return &RedisStore{do: do, prefix: newOptions(opts).prefix}
+}
+
+//Acquire locks a notification id for lockTimeout unless it is locked or done
+func (s *RedisStore) Acquire(ctx context.Context, id string, lockTimeout time.Duration) (State, string, error) {
+	token, err := newToken()
+	if err != nil {
+		return 0, "", err
+	}
+	key := s.prefix + id
+	for attempt := 0; attempt < 2; attempt++ {
+		reply, err := s.do(ctx, "SET", key, redisLock(token), "NX", "PX", redisMilliseconds(lockTimeout))
+		if err != nil {
+			return 0, "", err
+		}
+		if reply != nil {
+			return Acquired, token, nil
+		}
+
+		reply, err = s.do(ctx, "GET", key)
+		if err != nil {
+			return 0, "", err
+		}
+		switch value := replyString(reply); {
+		case value == redisDone:
+			return Done, "", nil
+		case strings.HasPrefix(value, redisInProgress):
+			return InProgress, "", nil
+		case value == "":
+			// expired in between
+			continue
+		default:
+			return 0, "", fmt.Errorf("unexpected value %q for %s", value, key)
+		}
+	}
+	return InProgress, "", nil
+}
+
+//Complete marks a notification id locked with token as done for the retention window,
+//unless another delivery took the lock over or completed it
+func (s *RedisStore) Complete(ctx context.Context, id string, token string, retention time.Duration) error {
+	_, err := s.do(ctx, "EVAL", redisCompareAndSet, "1", s.prefix+id, redisLock(token), redisDone, redisMilliseconds(retention))
+	return err
+}
+
+//Release unlocks a notification id locked with token whose processing failed.
+//The key is only deleted while it holds this lock, a done notification stays done.
+func (s *RedisStore) Release(ctx context.Context, id string, token string) error {
+	_, err := s.do(ctx, "EVAL", redisCompareAndDelete, "1", s.prefix+id, redisLock(token))
+	return err
+}
+
+//Returns the value of a key locked with token
+func redisLock(token string) string {
+	return redisInProgress + ":" + token
+}
+
+//Returns the PX argument of a duration, rounded up to a millisecond since Redis rejects PX 0
+func redisMilliseconds(d time.Duration) string {
+	ms := d.Milliseconds()
+	if d%time.Millisecond > 0 {
+		ms++
+	}
+	if ms < 1 {
+		ms = 1
+	}
+	return strconv.FormatInt(ms, 10)
+}
+
+//Returns the string of a bulk string reply
+func replyString(reply interface{}) string {
+	switch value := reply.(type) {
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	}
+	return ""
+}
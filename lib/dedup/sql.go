@@ -0,0 +1,187 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package dedup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+//DefaultTable is the table used by the SQL store
+const DefaultTable = "ebay_notification_dedup"
+
+//QuestionPlaceholder numbers query parameters as ?, used by MySQL and SQLite
+func QuestionPlaceholder(n int) string {
+	return "?"
+}
+
+//DollarPlaceholder numbers query parameters as $1, $2..., used by PostgreSQL
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+//WithTable sets the table of the SQL store, which must be a trusted identifier. Default: DefaultTable
+func WithTable(table string) Option {
+	return func(o *options) {
+		o.table = table
+	}
+}
+
+//WithPlaceholder sets the query parameter style of the SQL store. Default: QuestionPlaceholder
+func WithPlaceholder(placeholder func(n int) string) Option {
+	return func(o *options) {
+		o.placeholder = placeholder
+	}
+}
+
+//SQLStore keeps notification ids in a table shared by all instances.
+//The primary key on the notification id guarantees a single instance acquires it.
+type SQLStore struct {
+	db       *sql.DB
+	table    string
+	now      func() time.Time
+	lookup   string
+	take     string
+	complete string
+	insert   string
+	release  string
+	purge    string
+}
+
+//NewSQLStore is used to create a store on a database
+//Input
+//	db - database, with the table created by CreateTable
+//	opts - options, WithTable, WithPlaceholder and WithClock
+//Returns
+//	sql store
+func NewSQLStore(db *sql.DB, opts ...Option) *SQLStore {
+	o := newOptions(opts)
+	p := o.placeholder
+	return &SQLStore{
+		db:     db,
+		table:  o.table,
+		now:    o.now,
+		lookup: fmt.Sprintf("SELECT state, expires_at FROM %s WHERE notification_id = %s", o.table, p(1)),
+		take: fmt.Sprintf("UPDATE %s SET state = %s, expires_at = %s, token = %s WHERE notification_id = %s AND expires_at = %s",
+			o.table, p(1), p(2), p(3), p(4), p(5)),
+		complete: fmt.Sprintf("UPDATE %s SET state = %s, expires_at = %s, token = '' WHERE notification_id = %s AND "+
+			"((state = %s AND token = %s) OR expires_at <= %s)", o.table, p(1), p(2), p(3), p(4), p(5), p(6)),
+		insert:  fmt.Sprintf("INSERT INTO %s (notification_id, state, expires_at, token) VALUES (%s, %s, %s, %s)", o.table, p(1), p(2), p(3), p(4)),
+		release: fmt.Sprintf("DELETE FROM %s WHERE notification_id = %s AND state = %s AND token = %s", o.table, p(1), p(2), p(3)),
+		purge:   fmt.Sprintf("DELETE FROM %s WHERE expires_at <= %s", o.table, p(1)),
+	}
+}
+
+//CreateTable is used to create the table of the store when it does not exist
+func (s *SQLStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ("+
+		"notification_id VARCHAR(255) NOT NULL PRIMARY KEY, "+
+		"state INTEGER NOT NULL, "+
+		"expires_at BIGINT NOT NULL, "+
+		"token VARCHAR(64) NOT NULL)", s.table))
+	return err
+}
+
+//Acquire locks a notification id for lockTimeout unless it is locked or done
+func (s *SQLStore) Acquire(ctx context.Context, id string, lockTimeout time.Duration) (State, string, error) {
+	token, err := newToken()
+	if err != nil {
+		return 0, "", err
+	}
+	now := s.now()
+	expires := now.Add(lockTimeout).UnixNano()
+	for attempt := 0; attempt < 2; attempt++ {
+		_, insertErr := s.db.ExecContext(ctx, s.insert, id, int(InProgress), expires, token)
+		if insertErr == nil {
+			return Acquired, token, nil
+		}
+
+		// the insert failed on the primary key, or on the database
+		var state int
+		var current int64
+		err := s.db.QueryRowContext(ctx, s.lookup, id).Scan(&state, &current)
+		if errors.Is(err, sql.ErrNoRows) {
+			if attempt == 0 {
+				// released in between
+				continue
+			}
+			return 0, "", insertErr
+		} else if err != nil {
+			return 0, "", err
+		}
+		if now.UnixNano() < current {
+			return State(state), "", nil
+		}
+
+		// expired, take it over unless another instance did
+		res, err := s.db.ExecContext(ctx, s.take, int(InProgress), expires, token, id, current)
+		if err != nil {
+			return 0, "", err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return 0, "", err
+		} else if n == 1 {
+			return Acquired, token, nil
+		}
+		return InProgress, "", nil
+	}
+	return InProgress, "", nil
+}
+
+//Complete marks a notification id locked with token as done for the retention window,
+//unless another delivery took the lock over or completed it
+func (s *SQLStore) Complete(ctx context.Context, id string, token string, retention time.Duration) error {
+	now := s.now()
+	expires := now.Add(retention).UnixNano()
+	res, err := s.db.ExecContext(ctx, s.complete, int(Done), expires, id, int(InProgress), token, now.UnixNano())
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	// the row is held by another delivery, or was removed
+	_, insertErr := s.db.ExecContext(ctx, s.insert, id, int(Done), expires, "")
+	if insertErr == nil {
+		return nil
+	}
+	var state int
+	var current int64
+	if err := s.db.QueryRowContext(ctx, s.lookup, id).Scan(&state, &current); errors.Is(err, sql.ErrNoRows) {
+		return insertErr
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+//Release unlocks a notification id locked with token whose processing failed
+func (s *SQLStore) Release(ctx context.Context, id string, token string) error {
+	_, err := s.db.ExecContext(ctx, s.release, id, int(InProgress), token)
+	return err
+}
+
+//Purge is used to delete the expired notification ids
+func (s *SQLStore) Purge(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, s.purge, s.now().UnixNano())
+	return err
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+This package contains the sentinel errors shared by the SDK packages
+*/
+package errs
+
+import "errors"
+
+var (
+	//ErrMissingMessage is returned when no message or an empty body is provided
+	ErrMissingMessage = errors.New("missing message")
+	//ErrInvalidMessage is returned when the body cannot be decoded into a message
+	ErrInvalidMessage = errors.New("invalid message")
+	//ErrMissingSignature is returned when the X-EBAY-SIGNATURE header is missing
+	ErrMissingSignature = errors.New("missing signature")
+	//ErrInvalidSignature is returned when the signature does not verify the message
+	ErrInvalidSignature = errors.New("invalid signature")
+	//ErrInvalidConfig is returned when the config or environment is incomplete
+	ErrInvalidConfig = errors.New("invalid config")
+	//ErrKeyFetchFailed is returned when the public key cannot be fetched or parsed
+	ErrKeyFetchFailed = errors.New("public key fetch failed")
+	//ErrUnknownTopic is returned when no processor is registered for the topic
+	ErrUnknownTopic = errors.New("message processor not registered")
+	//ErrProcessorFailed is returned when the processor fails with a retryable error
+	ErrProcessorFailed = errors.New("message processor failed")
+	//ErrMissingChallengeCode is returned when no challenge code is provided
+	ErrMissingChallengeCode = errors.New("missing challenge code")
+	//ErrInProgress is returned when another delivery of the notification is being processed
+	ErrInProgress = errors.New("notification in progress")
+	//ErrDedupFailed is returned when the deduplication store cannot be reached
+	ErrDedupFailed = errors.New("deduplication store failed")
+	//ErrQueueFull is returned in async mode when no more notifications can be queued
+	ErrQueueFull = errors.New("notification queue full")
+	//ErrShuttingDown is returned in async mode once the client is shutting down
+	ErrShuttingDown = errors.New("shutting down")
+	//ErrOutboxFailed is returned when a notification cannot be persisted to the outbox
+	ErrOutboxFailed = errors.New("outbox failed")
+	//ErrStaleMessage is returned when the publish date of a notification is outside the freshness window
+	ErrStaleMessage = errors.New("stale message")
+	//ErrReplayedMessage is returned when a signature was already seen within the freshness window
+	ErrReplayedMessage = errors.New("replayed message")
+	//ErrInvalidDeadLetter is returned when a dead letter cannot be redriven, e.g. it has no message
+	ErrInvalidDeadLetter = errors.New("invalid dead letter")
+)
+
+//Error wraps the cause of a failure with the sentinel error classifying it,
+//so both can be matched with errors.Is
+type Error struct {
+	Kind error
+	Err  error
+}
+
+//Wrap is used to classify an error with a sentinel error
+//Input
+//	kind - sentinel error
+//	err - cause of the failure
+//Returns
+//	error matching both kind and err, nil if err is nil, err if it already matches kind
+func Wrap(kind error, err error) error {
+	if err == nil || errors.Is(err, kind) {
+		return err
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+//Is reports whether target is the sentinel classifying this error
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package fakeebay
+
+import (
+	"net/http/httptest"
+
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	service "github.com/ebay/event-notification-golang-sdk.git/lib/service"
+)
+
+//TestServer is a Server listening on a local TLS port, for tests
+type TestServer struct {
+	*Server
+	HTTP *httptest.Server
+}
+
+//StartTestServer is used to start a fake server on a local TLS port
+//Input
+//	opts - options of the server
+//Returns
+//	started server, to be closed
+//	error when a key cannot be generated
+func StartTestServer(opts ...Option) (*TestServer, error) {
+	server, err := NewServer(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &TestServer{Server: server, HTTP: httptest.NewTLSServer(server)}, nil
+}
+
+//Close is used to stop the server
+func (t *TestServer) Close() {
+	t.HTTP.Close()
+}
+
+//URL is the base URL of the server
+func (t *TestServer) URL() string {
+	return t.HTTP.URL
+}
+
+//Config is used to get a config using this server for both environments
+func (t *TestServer) Config() *pojo.Config {
+	return t.Server.Config(t.HTTP.URL)
+}
+
+//ClientOptions are the options of a notification.Client using this server: its public key API,
+//an HTTP client trusting its certificate and a key cache of its own
+func (t *TestServer) ClientOptions() []sdk.Option {
+	cache, _ := service.NewKeyCache(service.DefaultCacheSize)
+	return []sdk.Option{sdk.WithHTTPClient(t.HTTP.Client()), sdk.WithPublicKeyEndpoint(t.HTTP.URL + PublicKeyPath), sdk.WithCache(cache)}
+}
+
+//Publisher is used to create a publisher signing with the last key of the server
+func (t *TestServer) Publisher(opts ...PublisherOption) *Publisher {
+	return NewPublisher(t.Key(), opts...)
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+This package fakes the eBay APIs used by the SDK, and publishes signed notifications, for tests
+without credentials or network access
+*/
+package fakeebay
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"strings"
+
+	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	notificationtest "github.com/ebay/event-notification-golang-sdk.git/lib/notification/notificationtest"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//pemKidHeader is the PEM header holding the key id of a private key file
+const pemKidHeader = "Kid"
+
+//Key is a signing key and its key id, published with ECDSA/SHA1 as eBay does
+type Key struct {
+	ID      string
+	Private *ecdsa.PrivateKey
+}
+
+//GenerateKey is used to generate a P-256 key
+//Input
+//	kid - key id, random when empty
+//Returns
+//	key
+//	error
+func GenerateKey(kid string) (*Key, error) {
+	if kid == "" {
+		var err error
+		if kid, err = newUUID(); err != nil {
+			return nil, err
+		}
+	}
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{ID: kid, Private: private}, nil
+}
+
+//ParseKey is used to parse a private key file written with MarshalPEM
+//Input
+//	data - PEM encoded EC private key with a Kid header
+//Returns
+//	key
+//	error
+func ParseKey(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		return nil, errors.New("no EC PRIVATE KEY block")
+	}
+	private, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if block.Headers[pemKidHeader] == "" {
+		return nil, errors.New("private key without Kid header")
+	}
+	return &Key{ID: block.Headers[pemKidHeader], Private: private}, nil
+}
+
+//MarshalPEM is used to encode the private key and its key id
+func (k *Key) MarshalPEM() ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(k.Private)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Headers: map[string]string{pemKidHeader: k.ID}, Bytes: der}), nil
+}
+
+//PublicKeyPEM is used to get the public key as returned by the notification API, on a single line
+func (k *Key) PublicKeyPEM() (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(&k.Private.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	return constants.KeyStart + base64.StdEncoding.EncodeToString(der) + constants.KeyEnd, nil
+}
+
+//Response is used to get the public key response of the notification API
+func (k *Key) Response() (*pojo.Response, error) {
+	publicKey, err := k.PublicKeyPEM()
+	if err != nil {
+		return nil, err
+	}
+	return &pojo.Response{Key: publicKey, Algorithm: "ECDSA", Digest: "SHA1"}, nil
+}
+
+//KeyPair is used to get the key as a notificationtest key pair, signing with SHA1
+func (k *Key) KeyPair() *notificationtest.KeyPair {
+	return &notificationtest.KeyPair{Kid: k.ID, Private: k.Private, Digest: notificationtest.DefaultDigest}
+}
+
+//Sign is used to sign a body
+//Input
+//	body - notification body as sent
+//Returns
+//	X-EBAY-SIGNATURE header
+//	error
+func (k *Key) Sign(body []byte) (string, error) {
+	return k.KeyPair().Sign(body)
+}
+
+//Returns a random UUID, the format of eBay key and notification ids
+func newUUID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	encoded := hex.EncodeToString(id)
+	return strings.Join([]string{encoded[:8], encoded[8:12], encoded[12:16], encoded[16:20], encoded[20:]}, "-"), nil
+}
+
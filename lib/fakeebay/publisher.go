@@ -0,0 +1,220 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package fakeebay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	notificationtest "github.com/ebay/event-notification-golang-sdk.git/lib/notification/notificationtest"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+const (
+	//DefaultAttempts is the number of deliveries of a notification before giving up
+	DefaultAttempts = 3
+	//DefaultBackoff is the delay before the first redelivery, doubled for each redelivery
+	DefaultBackoff = time.Second
+
+	//TimeFormat is the format of the dates in notifications
+	TimeFormat = notificationtest.TimeFormat
+)
+
+//ErrNotDelivered is returned when the endpoint did not acknowledge a notification after all attempts
+var ErrNotDelivered = errors.New("notification not delivered")
+
+//Publisher signs notifications and POSTs them to an endpoint as eBay does, redelivering them
+//with an increasing publishAttemptCount until the endpoint answers with a 2xx status
+type Publisher struct {
+	key        *Key
+	httpClient *http.Client
+	attempts   int
+	backoff    time.Duration
+	now        func() time.Time
+}
+
+//PublisherOption configures a Publisher
+type PublisherOption func(*Publisher)
+
+//WithHTTPClient sets the client sending the notifications. Default: http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) PublisherOption {
+	return func(p *Publisher) {
+		p.httpClient = httpClient
+	}
+}
+
+//WithAttempts sets the number of deliveries of a notification. Default: DefaultAttempts
+func WithAttempts(attempts int) PublisherOption {
+	return func(p *Publisher) {
+		p.attempts = attempts
+	}
+}
+
+//WithBackoff sets the delay before the first redelivery. Default: DefaultBackoff
+func WithBackoff(backoff time.Duration) PublisherOption {
+	return func(p *Publisher) {
+		p.backoff = backoff
+	}
+}
+
+//WithPublisherClock sets the function returning the current time. Default: time.Now
+func WithPublisherClock(now func() time.Time) PublisherOption {
+	return func(p *Publisher) {
+		p.now = now
+	}
+}
+
+//DeliveryAttempt is one POST of a notification
+type DeliveryAttempt struct {
+	Number int
+	SentAt time.Time
+	//StatusCode is the status of the response, zero when the request failed
+	StatusCode int
+	Err        error
+}
+
+//Delivery is the outcome of publishing a notification
+type Delivery struct {
+	Attempts  []DeliveryAttempt
+	Delivered bool
+}
+
+//NewPublisher is used to create a publisher
+//Input
+//	key - signing key, published by the Server verifying the notifications
+//	opts - options overriding the defaults
+//Returns
+//	publisher
+func NewPublisher(key *Key, opts ...PublisherOption) *Publisher {
+	publisher := &Publisher{key: key, httpClient: http.DefaultClient, attempts: DefaultAttempts, backoff: DefaultBackoff, now: time.Now}
+	for _, opt := range opts {
+		opt(publisher)
+	}
+	return publisher
+}
+
+//NewMessage is used to create a notification with a random notificationId, dated now
+//Input
+//	topic - topic of the notification
+//Returns
+//	message, with empty data
+func NewMessage(topic string) (*pojo.Message, error) {
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Format(TimeFormat)
+	return &pojo.Message{
+		Metadata:     pojo.Metadata{Topic: topic, SchemaVersion: "1.0"},
+		Notification: pojo.Notification{NotificationID: id, EventDate: now, PublishDate: now, PublishAttemptCount: 1},
+	}, nil
+}
+
+//Publish is used to deliver a notification. Each attempt sets publishDate and publishAttemptCount,
+//so the body is signed again for every attempt.
+//Input
+//	ctx - context of the deliveries, redeliveries stop when it is done
+//	target - URL of the endpoint
+//	message - notification
+//Returns
+//	delivery
+//	error matching ErrNotDelivered, or the error of ctx
+func (p *Publisher) Publish(ctx context.Context, target string, message *pojo.Message) (*Delivery, error) {
+	attempt := *message
+	return p.deliver(ctx, target, func(number int) ([]byte, error) {
+		attempt.Notification.PublishDate = p.now().UTC().Format(TimeFormat)
+		attempt.Notification.PublishAttemptCount = number
+		return json.Marshal(&attempt)
+	})
+}
+
+//PublishRaw is used to deliver a body as is, every attempt sends the same body and signature
+//Input
+//	ctx - context of the deliveries, redeliveries stop when it is done
+//	target - URL of the endpoint
+//	body - notification body
+//Returns
+//	delivery
+//	error matching ErrNotDelivered, or the error of ctx
+func (p *Publisher) PublishRaw(ctx context.Context, target string, body []byte) (*Delivery, error) {
+	return p.deliver(ctx, target, func(int) ([]byte, error) {
+		return body, nil
+	})
+}
+
+//Deliver the bodies returned for each attempt until one is acknowledged
+func (p *Publisher) deliver(ctx context.Context, target string, body func(number int) ([]byte, error)) (*Delivery, error) {
+	delivery := &Delivery{}
+	backoff := p.backoff
+	for number := 1; ; number++ {
+		data, err := body(number)
+		if err != nil {
+			return delivery, err
+		}
+		attempt := DeliveryAttempt{Number: number, SentAt: p.now()}
+		attempt.StatusCode, attempt.Err = p.post(ctx, target, data)
+		delivery.Attempts = append(delivery.Attempts, attempt)
+		if attempt.Err == nil && attempt.StatusCode >= 200 && attempt.StatusCode < 300 {
+			delivery.Delivered = true
+			return delivery, nil
+		}
+		if number >= p.attempts {
+			if attempt.Err != nil {
+				return delivery, fmt.Errorf("%w after %d attempts: %v", ErrNotDelivered, number, attempt.Err)
+			}
+			return delivery, fmt.Errorf("%w after %d attempts, last status %d", ErrNotDelivered, number, attempt.StatusCode)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return delivery, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+//POST a signed body
+func (p *Publisher) post(ctx context.Context, target string, body []byte) (int, error) {
+	signature, err := p.key.Sign(body)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set(constants.ContentType, "application/json")
+	req.Header.Set(constants.XEbaySignature, signature)
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
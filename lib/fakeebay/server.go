@@ -0,0 +1,295 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package fakeebay
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+const (
+	//PublicKeyPath is the path of the public key API, followed by the key id
+	PublicKeyPath = "/commerce/notification/v1/public_key/"
+	//DefaultClientID is the client id accepted by default
+	DefaultClientID = "fake-client-id"
+	//DefaultClientSecret is the client secret accepted by default
+	DefaultClientSecret = "fake-client-secret"
+	//DefaultTokenTTL is the lifetime of the issued application tokens, as on eBay
+	DefaultTokenTTL = 2 * time.Hour
+)
+
+//Server fakes the identity and notification public key APIs of eBay
+type Server struct {
+	clientID     string
+	clientSecret string
+	tokenTTL     time.Duration
+	now          func() time.Time
+
+	mu     sync.RWMutex
+	keys   []*Key
+	tokens map[string]time.Time
+
+	tokenRequests uint64
+	keyRequests   uint64
+}
+
+//Stats are the counters of a Server
+type Stats struct {
+	//TokenRequests is the number of token requests, including rejected ones
+	TokenRequests uint64
+	//KeyRequests is the number of public key requests, including rejected ones
+	KeyRequests uint64
+}
+
+//Option configures a Server
+type Option func(*Server)
+
+//WithCredentials sets the accepted client credentials. Default: DefaultClientID and DefaultClientSecret
+func WithCredentials(clientID string, clientSecret string) Option {
+	return func(s *Server) {
+		s.clientID = clientID
+		s.clientSecret = clientSecret
+	}
+}
+
+//WithTokenTTL sets the lifetime of the issued tokens. Default: DefaultTokenTTL
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.tokenTTL = ttl
+	}
+}
+
+//WithClock sets the function returning the current time. Default: time.Now
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+//WithKey adds a signing key. Default: one generated key
+func WithKey(key *Key) Option {
+	return func(s *Server) {
+		s.keys = append(s.keys, key)
+	}
+}
+
+//NewServer is used to create a fake server
+//Input
+//	opts - options overriding the defaults
+//Returns
+//	server
+//	error when a key cannot be generated
+func NewServer(opts ...Option) (*Server, error) {
+	server := &Server{
+		clientID:     DefaultClientID,
+		clientSecret: DefaultClientSecret,
+		tokenTTL:     DefaultTokenTTL,
+		now:          time.Now,
+		tokens:       make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(server)
+	}
+	if len(server.keys) == 0 {
+		key, err := GenerateKey("")
+		if err != nil {
+			return nil, err
+		}
+		server.keys = append(server.keys, key)
+	}
+	return server, nil
+}
+
+//Key is used to get the signing key published last, e.g. to create a Publisher
+func (s *Server) Key() *Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys[len(s.keys)-1]
+}
+
+//Keys is used to get the published signing keys, oldest first
+func (s *Server) Keys() []*Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*Key(nil), s.keys...)
+}
+
+//AddKey is used to publish a signing key, e.g. to rotate keys
+func (s *Server) AddKey(key *Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, key)
+}
+
+//RemoveKey is used to stop publishing a signing key, its lookups then fail with 404
+func (s *Server) RemoveKey(kid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, key := range s.keys {
+		if key.ID == kid {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+//RevokeTokens is used to invalidate the issued tokens, the public key API then answers 401
+func (s *Server) RevokeTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]time.Time)
+}
+
+//Stats is used to get the counters of the server
+func (s *Server) Stats() Stats {
+	return Stats{TokenRequests: atomic.LoadUint64(&s.tokenRequests), KeyRequests: atomic.LoadUint64(&s.keyRequests)}
+}
+
+//Config is used to get a config using this server for both environments
+//Input
+//	baseURL - URL the server listens on, e.g. http://localhost:8080
+//Returns
+//	config with the accepted credentials, the endpoint and verification token are left empty
+func (s *Server) Config(baseURL string) *pojo.Config {
+	environment := pojo.Environment{BaseURL: baseURL, ClientID: s.clientID, ClientSecret: s.clientSecret}
+	return &pojo.Config{Sandbox: environment, Production: environment}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == constants.IdentifyPath:
+		s.token(w, r)
+	case strings.HasPrefix(r.URL.Path, PublicKeyPath):
+		s.publicKey(w, r, strings.TrimPrefix(r.URL.Path, PublicKeyPath))
+	default:
+		writeErrors(w, http.StatusNotFound, 2002, "Resource not found")
+	}
+}
+
+//Issue an application token for the client credentials grant
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	atomic.AddUint64(&s.tokenRequests, 1)
+	if r.Method != http.MethodPost {
+		writeErrors(w, http.StatusMethodNotAllowed, 2002, "Method not allowed")
+		return
+	}
+	if !s.authenticate(r.Header.Get(constants.Authorization)) {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get(constants.GrantType) != constants.ClientCredentials {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "grant type in request is not supported by the authorization server")
+		return
+	}
+
+	token, err := newUUID()
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	token = "v^1.1#fake#" + token
+	s.mu.Lock()
+	s.tokens[token] = s.now().Add(s.tokenTTL)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		constants.AccessToken: token,
+		"expires_in":          int64(s.tokenTTL / time.Second),
+		"token_type":          "Application Access Token",
+	})
+}
+
+//Check the Basic authorization of the client credentials, the SDK encodes them with the URL alphabet
+func (s *Server) authenticate(authorization string) bool {
+	if !strings.HasPrefix(authorization, constants.Basic) {
+		return false
+	}
+	encoded := strings.TrimPrefix(authorization, constants.Basic)
+	credentials, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		if credentials, err = base64.URLEncoding.DecodeString(encoded); err != nil {
+			return false
+		}
+	}
+	return string(credentials) == s.clientID+":"+s.clientSecret
+}
+
+//Serve the public key of a key id to a caller holding a valid token
+func (s *Server) publicKey(w http.ResponseWriter, r *http.Request, kid string) {
+	atomic.AddUint64(&s.keyRequests, 1)
+	if r.Method != http.MethodGet {
+		writeErrors(w, http.StatusMethodNotAllowed, 2002, "Method not allowed")
+		return
+	}
+	authorization := r.Header.Get(constants.Authorization)
+	if len(authorization) < len(constants.Bearer) || !strings.EqualFold(authorization[:len(constants.Bearer)], constants.Bearer) {
+		writeErrors(w, http.StatusUnauthorized, 1001, "Invalid access token")
+		return
+	}
+	s.mu.RLock()
+	expires, ok := s.tokens[authorization[len(constants.Bearer):]]
+	var key *Key
+	for _, candidate := range s.keys {
+		if candidate.ID == kid {
+			key = candidate
+		}
+	}
+	s.mu.RUnlock()
+	if !ok || !s.now().Before(expires) {
+		writeErrors(w, http.StatusUnauthorized, 1001, "Invalid access token")
+		return
+	}
+	if key == nil {
+		writeErrors(w, http.StatusNotFound, 195000, "Resource not found")
+		return
+	}
+
+	response, err := key.Response()
+	if err != nil {
+		writeErrors(w, http.StatusInternalServerError, 195001, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set(constants.ContentType, "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+//Write an error of the identity API
+func writeOAuthError(w http.ResponseWriter, status int, code string, description string) {
+	writeJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+//Write an error of the RESTful APIs
+func writeErrors(w http.ResponseWriter, status int, errorID int, message string) {
+	writeJSON(w, status, map[string]interface{}{"errors": []map[string]interface{}{{
+		"errorId":  errorID,
+		"domain":   "API_NOTIFICATION",
+		"category": "REQUEST",
+		"message":  message,
+	}}})
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package helper
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	service "github.com/ebay/event-notification-golang-sdk.git/lib/service"
+)
+
+//Names of the diagnostic steps
+const (
+	StepDecodeHeader     = "decode header"
+	StepFetchKey         = "fetch public key"
+	StepResolveAlgorithm = "resolve algorithm"
+	StepDecodeSignature  = "decode signature"
+	StepVerifyRaw        = "verify raw body"
+	StepVerifyMessage    = "verify re-marshalled message"
+)
+
+//DiagnosticStep is one check of a signature diagnosis
+type DiagnosticStep struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+//Diagnosis explains why a signature is accepted or rejected
+type Diagnosis struct {
+	//Header is the decoded X-EBAY-SIGNATURE header, nil when it cannot be decoded
+	Header *pojo.XeBaySignatureHeader
+	//Key is the public key of the header kid, nil when it cannot be fetched
+	Key       *service.KeyInfo
+	Algorithm Algorithm
+	Steps     []DiagnosticStep
+	//Hints are likely causes of a failure
+	Hints []string
+	//Err is the error of ValidateRawSignature, nil when the raw body verifies
+	Err error
+	//MessageErr is the error of ValidateSignature, which verifies the re-marshalled message
+	MessageErr error
+}
+
+//Record a step
+func (d *Diagnosis) step(name string, err error, detail string) bool {
+	if err != nil {
+		detail = err.Error()
+	}
+	d.Steps = append(d.Steps, DiagnosticStep{Name: name, OK: err == nil, Detail: detail})
+	return err == nil
+}
+
+//Diagnose is used to verify a signature step by step, to investigate rejected notifications
+//Input
+//	ctx - context of the key lookup
+//	body - raw request body
+//	signatureHeader - base64 encoded signature
+//	keys - provider of the public keys
+//Returns
+//	diagnosis, the steps following a failed one are skipped
+func Diagnose(ctx context.Context, body []byte, signatureHeader string, keys service.KeyProvider) *Diagnosis {
+	diagnosis := &Diagnosis{}
+	header, err := DecodeSignatureHeader(signatureHeader)
+	if err != nil {
+		diagnosis.fail(StepDecodeHeader, err)
+		return diagnosis
+	}
+	diagnosis.Header = header
+	diagnosis.step(StepDecodeHeader, nil, fmt.Sprintf("kid=%s alg=%s digest=%s", header.Kid, header.Alg, header.Digest))
+
+	info, err := service.LookupKey(ctx, keys, header.Kid)
+	if err != nil {
+		diagnosis.fail(StepFetchKey, err)
+		return diagnosis
+	}
+	diagnosis.Key = info
+	detail := describeKey(info.Key)
+	if info.Algorithm != "" || info.Digest != "" {
+		detail += fmt.Sprintf(", published with alg=%s digest=%s", info.Algorithm, info.Digest)
+	}
+	diagnosis.step(StepFetchKey, nil, detail)
+
+	algorithm, err := ResolveAlgorithm(header, &pojo.Response{Algorithm: info.Algorithm, Digest: info.Digest})
+	diagnosis.Algorithm = algorithm
+	if err != nil {
+		diagnosis.fail(StepResolveAlgorithm, err)
+		return diagnosis
+	}
+	diagnosis.step(StepResolveAlgorithm, nil, algorithm.String())
+
+	signature, err := base64.StdEncoding.DecodeString(header.Signature)
+	if err != nil {
+		diagnosis.fail(StepDecodeSignature, err)
+		return diagnosis
+	}
+	diagnosis.step(StepDecodeSignature, nil, fmt.Sprintf("%d bytes", len(signature)))
+
+	diagnosis.Err = Verify(algorithm, info.Key, body, signature)
+	diagnosis.step(StepVerifyRaw, diagnosis.Err, fmt.Sprintf("%d bytes verified", len(body)))
+
+	var message pojo.Message
+	if err := json.Unmarshal(body, &message); err != nil {
+		diagnosis.MessageErr = err
+		diagnosis.step(StepVerifyMessage, err, "")
+	} else {
+		marshalled, _ := json.Marshal(&message)
+		diagnosis.MessageErr = Verify(algorithm, info.Key, marshalled, signature)
+		diagnosis.step(StepVerifyMessage, diagnosis.MessageErr, fmt.Sprintf("%d bytes verified", len(marshalled)))
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	switch {
+	case diagnosis.Err == nil && diagnosis.MessageErr != nil:
+		diagnosis.Hints = append(diagnosis.Hints, "pojo.Message does not round-trip the body: use ValidateRawSignature instead of ValidateSignature")
+	case diagnosis.Err == nil:
+	case len(trimmed) != len(body) && Verify(algorithm, info.Key, trimmed, signature) == nil:
+		diagnosis.Hints = append(diagnosis.Hints, "the body verifies without its leading or trailing whitespace, which was added after signing")
+	case diagnosis.MessageErr == nil:
+		diagnosis.Hints = append(diagnosis.Hints, "the body was re-encoded after signing: ValidateSignature passes, ValidateRawSignature fails")
+	default:
+		diagnosis.Hints = append(diagnosis.Hints, "the body does not match the signature: check it is the exact request body and the kid is the right key")
+	}
+	return diagnosis
+}
+
+//Record a failed step, and its error as the verification error
+func (d *Diagnosis) fail(name string, err error) {
+	d.Err = err
+	d.MessageErr = err
+	d.step(name, err, "")
+}
+
+//Returns a description of a public key
+func describeKey(key crypto.PublicKey) string {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		return "ECDSA " + k.Curve.Params().Name + " key"
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d bits key", k.N.BitLen())
+	case ed25519.PublicKey:
+		return "Ed25519 key"
+	}
+	return fmt.Sprintf("%T key", key)
+}
@@ -17,102 +17,146 @@ This package contains required helper functions
 package helper
 
 import (
-	"crypto/ecdsa"
-	"crypto/sha1"
+	"context"
 	"crypto/sha256"
-	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
-	"encoding/pem"
-	"fmt"
-	"strings"
+	"errors"
 
 	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	errs "github.com/ebay/event-notification-golang-sdk.git/lib/errs"
+	logging "github.com/ebay/event-notification-golang-sdk.git/lib/logging"
 	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
 	service "github.com/ebay/event-notification-golang-sdk.git/lib/service"
 )
 
-//Get XeBay Signature header takes in signature decode using base64 and returns it
-//Returns base64 decoded signature
+//DecodeSignatureHeader is used to decode the base64 encoded JSON of the X-EBAY-SIGNATURE header
 //Input
 //	signatureHeader - base64 encoded signature
 //Returns
-//	base64 decoded signature
-func getXeBaySignatureHeader(signatureHeader string) *pojo.XeBaySignatureHeader {
+//	decoded header
+//	error wrapping errs.ErrInvalidSignature when the header is malformed
+func DecodeSignatureHeader(signatureHeader string) (*pojo.XeBaySignatureHeader, error) {
 	rawDecodedText, err := base64.StdEncoding.DecodeString(signatureHeader)
 	if err != nil {
-		panic(err)
+		return nil, errs.Wrap(errs.ErrInvalidSignature, err)
 	}
 	var signature pojo.XeBaySignatureHeader
-	json.Unmarshal([]byte(rawDecodedText), &signature)
-	return &signature
+	if err := json.Unmarshal([]byte(rawDecodedText), &signature); err != nil {
+		return nil, errs.Wrap(errs.ErrInvalidSignature, err)
+	}
+	if signature.Kid == "" || signature.Signature == "" {
+		return nil, errs.Wrap(errs.ErrInvalidSignature, errors.New("signature header without kid or signature"))
+	}
+	return &signature, nil
 }
 
-//The format key function convert key by adding newline before/after comments
+//ValidateSignature is to validate signature used in request.
+//The message is re-marshalled before verification, prefer ValidateRawSignature
+//when the request body is available.
+//Returns string Success/Error
 //Input
-//	key - unformatted key
+//	message - message details
+//	signatureHeader - base64 encoded signature
+//	config - specific custom environment
 //Returns
-//	key - formatted key
-func formatKey(key string) string {
-
-	key = strings.Replace(key, constants.KeyStart, fmt.Sprintf("%s\n", constants.KeyStart), 1)
-	key = strings.Replace(key, constants.KeyEnd, fmt.Sprintf("\n%s", constants.KeyEnd), 1)
-
-	return key
+//	string Success/Error
+func ValidateSignature(message *pojo.Message, signatureHeader string, config *pojo.CustomEnvironment) string {
+	if err := VerifySignature(message, signatureHeader, config); err != nil {
+		logValidationFailure(signatureHeader, err)
+		return constants.Error
+	}
+	return constants.Success
 }
 
-//ValidateSignature is to validate signature used in request
+//ValidateRawSignature is to validate signature against the request body as received
 //Returns string Success/Error
 //Input
-//	message - message details 
+//	body - raw request body
 //	signatureHeader - base64 encoded signature
 //	config - specific custom environment
 //Returns
 //	string Success/Error
-func ValidateSignature(message *pojo.Message, signatureHeader string, config *pojo.CustomEnvironment) string {
-
-	// Base64 decode the signatureHeader and convert to JSON
-	xeBaySignature := getXeBaySignatureHeader(signatureHeader)
-
-	// // Get the public key
-	publicKey := service.GetPublicKey(xeBaySignature.Kid, config)
-
-	var pubPEMData = []byte(formatKey(publicKey.Key))
-	block, _ := pem.Decode(pubPEMData)
-	if block == nil {
-		fmt.Println("Invalid PEM Block")
+func ValidateRawSignature(body []byte, signatureHeader string, config *pojo.CustomEnvironment) string {
+	if err := VerifyRawSignature(body, signatureHeader, config); err != nil {
+		logValidationFailure(signatureHeader, err)
 		return constants.Error
 	}
+	return constants.Success
+}
+
+//Log a failed signature validation with the kid of the header when it can be decoded
+func logValidationFailure(signatureHeader string, err error) {
+	fields := []any{logging.KeyOutcome, logging.OutcomeRejected, logging.KeyError, err}
+	if header, decodeErr := DecodeSignatureHeader(signatureHeader); decodeErr == nil {
+		fields = append([]any{logging.KeyKid, header.Kid}, fields...)
+	}
+	logging.Default().Warn("Signature validation failed", fields...)
+}
 
-	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+//VerifySignature is to verify signature used in request against the re-marshalled message
+//Input
+//	message - message details
+//	signatureHeader - base64 encoded signature
+//	config - specific custom environment
+//Returns
+//	error wrapping errs.ErrInvalidSignature or errs.ErrKeyFetchFailed
+func VerifySignature(message *pojo.Message, signatureHeader string, config *pojo.CustomEnvironment) error {
+	byteArr, err := json.Marshal(message)
 	if err != nil {
-		fmt.Println(err)
-		return constants.Error
+		return errs.Wrap(errs.ErrInvalidMessage, err)
 	}
+	return VerifyRawSignature(byteArr, signatureHeader, config)
+}
 
-	pubKey := key.(*ecdsa.PublicKey)
-	signature, err := base64.StdEncoding.DecodeString(xeBaySignature.Signature)
+//VerifyRawSignature is to verify signature against the request body as received
+//Input
+//	body - raw request body
+//	signatureHeader - base64 encoded signature
+//	config - specific custom environment
+//Returns
+//	error wrapping errs.ErrInvalidSignature or errs.ErrKeyFetchFailed
+func VerifyRawSignature(body []byte, signatureHeader string, config *pojo.CustomEnvironment) error {
+	return VerifyRaw(context.Background(), body, signatureHeader, service.DefaultKeyCache.Provider(service.NewRemoteKeyProvider(config, nil)))
+}
+
+//VerifyRaw is to verify signature against the request body with keys from a key provider.
+//When the provider implements service.KeyInfoProvider, the header algorithm is
+//cross-checked against the algorithm published with the key.
+//Input
+//	ctx - context of the key lookup
+//	body - raw request body
+//	signatureHeader - base64 encoded signature
+//	keys - provider of the public keys
+//Returns
+//	error wrapping errs.ErrInvalidSignature or errs.ErrKeyFetchFailed
+func VerifyRaw(ctx context.Context, body []byte, signatureHeader string, keys service.KeyProvider) error {
+
+	// Base64 decode the signatureHeader and convert to JSON
+	xeBaySignature, err := DecodeSignatureHeader(signatureHeader)
 	if err != nil {
-		fmt.Println(err)
-		return constants.Error
+		return err
 	}
 
-	byteArr, err := json.Marshal(message)
+	// Get the public key
+	info, err := service.LookupKey(ctx, keys, xeBaySignature.Kid)
 	if err != nil {
-		fmt.Println(err)
-		return constants.Error
+		return errs.Wrap(errs.ErrKeyFetchFailed, err)
 	}
+	published := &pojo.Response{Algorithm: info.Algorithm, Digest: info.Digest}
 
-	hash := sha1.Sum(byteArr)
-	success := ecdsa.VerifyASN1(pubKey, hash[:], signature)
+	algorithm, err := ResolveAlgorithm(xeBaySignature, published)
+	if err != nil {
+		return errs.Wrap(errs.ErrInvalidSignature, err)
+	}
 
-	if success != true {
-		fmt.Println(err)
-		return constants.Error
+	signature, err := base64.StdEncoding.DecodeString(xeBaySignature.Signature)
+	if err != nil {
+		return errs.Wrap(errs.ErrInvalidSignature, err)
 	}
 
-	return constants.Success
+	return errs.Wrap(errs.ErrInvalidSignature, Verify(algorithm, info.Key, body, signature))
 }
 
 //GenerateChallengeResponse is used to generate challenge response for given challenge code
@@ -0,0 +1,290 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package helper
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+const (
+	//AlgECDSA is the alg value of ECDSA signatures
+	AlgECDSA = "ECDSA"
+	//AlgEd25519 is the alg value of Ed25519 signatures
+	AlgEd25519 = "ED25519"
+	//AlgRSAPSS is the alg value of RSASSA-PSS signatures
+	AlgRSAPSS = "RSA-PSS"
+
+	//DigestSHA1 is the SHA-1 digest
+	DigestSHA1 = "SHA1"
+	//DigestSHA256 is the SHA-256 digest
+	DigestSHA256 = "SHA256"
+	//DigestSHA384 is the SHA-384 digest
+	DigestSHA384 = "SHA384"
+	//DigestSHA512 is the SHA-512 digest
+	DigestSHA512 = "SHA512"
+	//DigestNone is used by algorithms signing the message itself, such as Ed25519
+	DigestNone = "NONE"
+)
+
+var (
+	//ErrUnsupportedAlgorithm is returned when no verifier is registered for the alg/digest pair
+	ErrUnsupportedAlgorithm = errors.New("unsupported signature algorithm")
+	//ErrAlgorithmNotAllowed is returned when the alg/digest pair is not in the allowlist
+	ErrAlgorithmNotAllowed = errors.New("signature algorithm not allowed")
+	//ErrAlgorithmMismatch is returned when the signature header disagrees with the public key metadata
+	ErrAlgorithmMismatch = errors.New("signature algorithm does not match public key")
+	//ErrKeyType is returned when the public key cannot be used with the algorithm
+	ErrKeyType = errors.New("public key type does not match signature algorithm")
+	//ErrVerification is returned when the signature does not match the message
+	ErrVerification = errors.New("signature verification failed")
+)
+
+//Algorithm is a signature algorithm and digest pair as found in the X-EBAY-SIGNATURE header
+type Algorithm struct {
+	Alg    string
+	Digest string
+}
+
+func (a Algorithm) String() string {
+	return a.Alg + "/" + a.Digest
+}
+
+//Verifier checks a signature over data with a public key
+type Verifier interface {
+	Verify(key crypto.PublicKey, data []byte, signature []byte) error
+}
+
+//VerifierFunc is an adapter to use an ordinary function as a Verifier
+type VerifierFunc func(key crypto.PublicKey, data []byte, signature []byte) error
+
+//Verify calls f(key, data, signature)
+func (f VerifierFunc) Verify(key crypto.PublicKey, data []byte, signature []byte) error {
+	return f(key, data, signature)
+}
+
+var (
+	verifiersMu sync.RWMutex
+	verifiers   = map[Algorithm]Verifier{}
+	allowed     = map[Algorithm]bool{}
+)
+
+func init() {
+	for _, digest := range []string{DigestSHA1, DigestSHA256, DigestSHA384} {
+		registerVerifier(Algorithm{AlgECDSA, digest}, ecdsaVerifier(digestHash(digest)))
+	}
+	for _, digest := range []string{DigestSHA256, DigestSHA384, DigestSHA512} {
+		registerVerifier(Algorithm{AlgRSAPSS, digest}, rsaPSSVerifier(digestHash(digest)))
+	}
+	// Ed25519 hashes internally, some publishers still name SHA-512 as the digest
+	registerVerifier(Algorithm{AlgEd25519, DigestNone}, VerifierFunc(verifyEd25519))
+	registerVerifier(Algorithm{AlgEd25519, DigestSHA512}, VerifierFunc(verifyEd25519))
+}
+
+func registerVerifier(algorithm Algorithm, v Verifier) {
+	verifiers[algorithm] = v
+	allowed[algorithm] = true
+}
+
+//RegisterVerifier is used to add or replace the verifier of an alg/digest pair.
+//The pair is added to the allowlist.
+//Input
+//	alg - signature algorithm, e.g. ECDSA
+//	digest - digest algorithm, e.g. SHA256
+//	v - verifier for the pair
+func RegisterVerifier(alg string, digest string, v Verifier) {
+	verifiersMu.Lock()
+	defer verifiersMu.Unlock()
+	registerVerifier(NormalizeAlgorithm(alg, digest), v)
+}
+
+//SetAllowedAlgorithms is used to restrict the alg/digest pairs accepted for verification,
+//preventing a downgrade to a weaker algorithm. By default every registered pair is allowed.
+//Input
+//	algorithms - accepted alg/digest pairs
+func SetAllowedAlgorithms(algorithms ...Algorithm) {
+	verifiersMu.Lock()
+	defer verifiersMu.Unlock()
+	allowed = make(map[Algorithm]bool, len(algorithms))
+	for _, algorithm := range algorithms {
+		allowed[NormalizeAlgorithm(algorithm.Alg, algorithm.Digest)] = true
+	}
+}
+
+//AllowedAlgorithms is used to list the alg/digest pairs accepted for verification
+//Returns
+//	accepted alg/digest pairs
+func AllowedAlgorithms() []Algorithm {
+	verifiersMu.RLock()
+	defer verifiersMu.RUnlock()
+	algorithms := make([]Algorithm, 0, len(allowed))
+	for algorithm := range allowed {
+		algorithms = append(algorithms, algorithm)
+	}
+	return algorithms
+}
+
+//NormalizeAlgorithm is used to convert the alg/digest spellings used by eBay into canonical names
+//Input
+//	alg - signature algorithm, e.g. ecdsa
+//	digest - digest algorithm, e.g. SHA-256
+//Returns
+//	canonical alg/digest pair
+func NormalizeAlgorithm(alg string, digest string) Algorithm {
+	alg = strings.ToUpper(strings.TrimSpace(alg))
+	switch alg {
+	case "RSASSA-PSS", "RSAPSS", "RSA_PSS":
+		alg = AlgRSAPSS
+	case "EDDSA":
+		alg = AlgEd25519
+	}
+	digest = strings.ToUpper(strings.TrimSpace(digest))
+	digest = strings.NewReplacer("-", "", "_", "").Replace(digest)
+	if digest == "" {
+		digest = DigestNone
+	}
+	return Algorithm{Alg: alg, Digest: digest}
+}
+
+//ResolveAlgorithm is used to determine the algorithm of a signature, cross-checked
+//against the algorithm and digest eBay published with the public key
+//Input
+//	header - decoded X-EBAY-SIGNATURE header
+//	publicKey - public key response, may be nil
+//Returns
+//	canonical alg/digest pair
+//	error on mismatch
+func ResolveAlgorithm(header *pojo.XeBaySignatureHeader, publicKey *pojo.Response) (Algorithm, error) {
+	alg, digest := header.Alg, header.Digest
+	if publicKey != nil {
+		if alg == "" {
+			alg = publicKey.Algorithm
+		}
+		if digest == "" {
+			digest = publicKey.Digest
+		}
+	}
+	algorithm := NormalizeAlgorithm(alg, digest)
+	if algorithm.Alg == "" {
+		return algorithm, ErrUnsupportedAlgorithm
+	}
+	if publicKey != nil && (publicKey.Algorithm != "" || publicKey.Digest != "") {
+		published := NormalizeAlgorithm(publicKey.Algorithm, publicKey.Digest)
+		if publicKey.Algorithm != "" && published.Alg != algorithm.Alg {
+			return algorithm, fmt.Errorf("%w: header %s, key %s", ErrAlgorithmMismatch, algorithm, published)
+		}
+		if publicKey.Digest != "" && published.Digest != algorithm.Digest {
+			return algorithm, fmt.Errorf("%w: header %s, key %s", ErrAlgorithmMismatch, algorithm, published)
+		}
+	}
+	return algorithm, nil
+}
+
+//Verify is used to check a signature with the verifier registered for the alg/digest pair
+//Input
+//	algorithm - alg/digest pair
+//	key - public key
+//	data - signed data
+//	signature - decoded signature
+//Returns
+//	error if the pair is not allowed or the signature does not match
+func Verify(algorithm Algorithm, key crypto.PublicKey, data []byte, signature []byte) error {
+	algorithm = NormalizeAlgorithm(algorithm.Alg, algorithm.Digest)
+	verifiersMu.RLock()
+	v, registered := verifiers[algorithm]
+	isAllowed := allowed[algorithm]
+	verifiersMu.RUnlock()
+
+	if !registered {
+		return fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+	if !isAllowed {
+		return fmt.Errorf("%w: %s", ErrAlgorithmNotAllowed, algorithm)
+	}
+	return v.Verify(key, data, signature)
+}
+
+//Returns the crypto.Hash of a canonical digest name
+func digestHash(digest string) crypto.Hash {
+	switch digest {
+	case DigestSHA1:
+		return crypto.SHA1
+	case DigestSHA256:
+		return crypto.SHA256
+	case DigestSHA384:
+		return crypto.SHA384
+	case DigestSHA512:
+		return crypto.SHA512
+	}
+	return 0
+}
+
+//Returns the hash of data using the given digest
+func hashData(hash crypto.Hash, data []byte) []byte {
+	hasher := hash.New()
+	hasher.Write(data)
+	return hasher.Sum(nil)
+}
+
+func ecdsaVerifier(hash crypto.Hash) Verifier {
+	return VerifierFunc(func(key crypto.PublicKey, data []byte, signature []byte) error {
+		pubKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %T", ErrKeyType, key)
+		}
+		if !ecdsa.VerifyASN1(pubKey, hashData(hash, data), signature) {
+			return ErrVerification
+		}
+		return nil
+	})
+}
+
+func rsaPSSVerifier(hash crypto.Hash) Verifier {
+	return VerifierFunc(func(key crypto.PublicKey, data []byte, signature []byte) error {
+		pubKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %T", ErrKeyType, key)
+		}
+		options := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: hash}
+		if err := rsa.VerifyPSS(pubKey, hash, hashData(hash, data), signature, options); err != nil {
+			return ErrVerification
+		}
+		return nil
+	})
+}
+
+func verifyEd25519(key crypto.PublicKey, data []byte, signature []byte) error {
+	pubKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrKeyType, key)
+	}
+	if !ed25519.Verify(pubKey, data, signature) {
+		return ErrVerification
+	}
+	return nil
+}
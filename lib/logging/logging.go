@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+This package provides the structured logging of the SDK
+*/
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+//Keys of the fields logged by the SDK
+const (
+	KeyTopic          = "topic"
+	KeyNotificationID = "notificationId"
+	KeyKid            = "kid"
+	KeyAttempt        = "attempt"
+	KeyOutcome        = "outcome"
+	KeyError          = "error"
+)
+
+//Outcomes logged with KeyOutcome
+const (
+	OutcomeProcessed    = "processed"
+	OutcomeQueued       = "queued"
+	OutcomeDuplicate    = "duplicate"
+	OutcomeDropped      = "dropped"
+	OutcomeDeadLettered = "dead_lettered"
+	OutcomeFailed       = "failed"
+	OutcomeRejected     = "rejected"
+)
+
+//Logger receives the structured log output of the SDK, *slog.Logger satisfies it.
+//The arguments are slog.Attr values or alternating keys and values, as with slog.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+//Printer is a logger in the format of the log package, *log.Logger satisfies it
+type Printer interface {
+	Println(v ...interface{})
+}
+
+var (
+	mu            sync.RWMutex
+	defaultLogger Logger = Redacting(slogDefault{}, Hash)
+)
+
+//Default is used to get the logger of the package level functions
+//Returns
+//	logger set with SetDefault, or slog.Default with the PII fields hashed
+func Default() Logger {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultLogger
+}
+
+//SetDefault is used to set the logger of the package level functions and of clients without logger.
+//PII fields are hashed unless the logger is already wrapped with Redacting.
+//Input
+//	logger - logger, nil to restore slog.Default
+func SetDefault(logger Logger) {
+	if logger == nil {
+		logger = slogDefault{}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	defaultLogger = Redacting(logger, Hash)
+}
+
+//contextKey is the key of the logger in a context
+type contextKey struct{}
+
+//NewContext is used to pass a logger on to the processors, e.g. the logger of a notification.Client
+//Input
+//	ctx - parent context
+//	logger - logger
+//Returns
+//	context carrying the logger
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+//FromContext is used to get the logger passed on with NewContext
+//Input
+//	ctx - context
+//Returns
+//	logger of the context, or Default when there is none
+func FromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(contextKey{}).(Logger); ok && logger != nil {
+		return logger
+	}
+	return Default()
+}
+
+//slogDefault logs to slog.Default at the time of the call, so it follows slog.SetDefault
+type slogDefault struct{}
+
+func (slogDefault) Debug(msg string, args ...any) { slog.Default().Debug(msg, args...) }
+func (slogDefault) Info(msg string, args ...any)  { slog.Default().Info(msg, args...) }
+func (slogDefault) Warn(msg string, args ...any)  { slog.Default().Warn(msg, args...) }
+func (slogDefault) Error(msg string, args ...any) { slog.Default().Error(msg, args...) }
+
+//printerLogger adapts a Printer to the Logger interface
+type printerLogger struct {
+	printer Printer
+}
+
+//FromPrinter is used to log through a Printer, one line per message with its level and fields.
+//Debug messages are dropped.
+//Input
+//	printer - logger in the format of the log package
+//Returns
+//	logger
+func FromPrinter(printer Printer) Logger {
+	return printerLogger{printer}
+}
+
+func (p printerLogger) Debug(msg string, args ...any) {}
+func (p printerLogger) Info(msg string, args ...any)  { p.print(slog.LevelInfo, msg, args) }
+func (p printerLogger) Warn(msg string, args ...any)  { p.print(slog.LevelWarn, msg, args) }
+func (p printerLogger) Error(msg string, args ...any) { p.print(slog.LevelError, msg, args) }
+
+func (p printerLogger) print(level slog.Level, msg string, args []any) {
+	var line strings.Builder
+	line.WriteString(level.String())
+	line.WriteString(" ")
+	line.WriteString(msg)
+	for _, attr := range attrs(args) {
+		writeAttr(&line, "", attr)
+	}
+	p.printer.Println(line.String())
+}
+
+//Write an attribute as key=value, flattening groups into dotted keys
+func writeAttr(line *strings.Builder, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		for _, member := range value.Group() {
+			writeAttr(line, prefix+attr.Key+".", member)
+		}
+		return
+	}
+	fmt.Fprintf(line, " %s%s=%v", prefix, attr.Key, value)
+}
+
+//Convert slog style arguments into attributes
+func attrs(args []any) []slog.Attr {
+	var list []slog.Attr
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i].(type) {
+		case slog.Attr:
+			list = append(list, arg)
+		case string:
+			if i+1 < len(args) {
+				list = append(list, slog.Any(arg, args[i+1]))
+				i++
+			} else {
+				list = append(list, slog.String("!BADKEY", arg))
+			}
+		default:
+			list = append(list, slog.Any("!BADKEY", arg))
+		}
+	}
+	return list
+}
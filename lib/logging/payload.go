@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+)
+
+//payload logs a notification payload as a group of its JSON fields
+type payload struct {
+	data any
+}
+
+//Payload is used to log a notification payload, e.g. a pojo.PayloadData, as a group of its JSON fields
+//in their declaration order, so loggers wrapped with Redacting can hide the PII ones
+//Input
+//	data - payload, encoded with encoding/json
+//Returns
+//	log value
+func Payload(data any) slog.LogValuer {
+	return payload{data: data}
+}
+
+func (p payload) LogValue() slog.Value {
+	encoded, err := json.Marshal(p.data)
+	if err != nil {
+		return slog.AnyValue(p.data)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	value, err := decodeValue(decoder)
+	if err != nil {
+		return slog.AnyValue(p.data)
+	}
+	return value
+}
+
+//Decode the next JSON value, objects become groups keeping the order of their fields and arrays groups keyed by index
+func decodeValue(decoder *json.Decoder) (slog.Value, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return slog.Value{}, err
+	}
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '[' {
+			// arrays become groups keyed by index, so the PII fields of their elements are hidden too
+			var items []slog.Attr
+			for decoder.More() {
+				item, err := decodeValue(decoder)
+				if err != nil {
+					return slog.Value{}, err
+				}
+				items = append(items, slog.Attr{Key: strconv.Itoa(len(items)), Value: item})
+			}
+			_, err := decoder.Token()
+			return slog.GroupValue(items...), err
+		}
+		var fields []slog.Attr
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return slog.Value{}, err
+			}
+			value, err := decodeValue(decoder)
+			if err != nil {
+				return slog.Value{}, err
+			}
+			fields = append(fields, slog.Attr{Key: key.(string), Value: value})
+		}
+		_, err := decoder.Token()
+		return slog.GroupValue(fields...), err
+	case json.Number:
+		if n, err := token.Int64(); err == nil {
+			return slog.Int64Value(n), nil
+		}
+		f, _ := token.Float64()
+		return slog.Float64Value(f), nil
+	}
+	return slog.AnyValue(token), nil
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+//Mode is how the value of a PII field is hidden
+type Mode int
+
+const (
+	//Hash replaces the value with an HMAC-SHA256 keyed with the hash key, so log lines of a user can
+	//still be correlated without the value being recoverable from a dictionary of known values
+	Hash Mode = iota
+	//Redact replaces the value with RedactedValue
+	Redact
+)
+
+//RedactedValue replaces the value of PII fields in Redact mode
+const RedactedValue = "[REDACTED]"
+
+//DefaultPIIFields are the fields of the notification payloads identifying a user
+var DefaultPIIFields = []string{"username", "userId", "eiasToken"}
+
+var (
+	hashMu  sync.RWMutex
+	hashKey = newHashKey()
+)
+
+//Returns a random key, so hashes can only be correlated within a process unless SetHashKey is used
+func newHashKey() []byte {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
+
+//SetHashKey is used to set the key of the PII hashes, e.g. to correlate log lines of several instances.
+//The key must be kept secret. Default: a random key per process
+//Input
+//	key - HMAC key, nil to generate a random key
+func SetHashKey(key []byte) {
+	if key == nil {
+		key = newHashKey()
+	}
+	hashMu.Lock()
+	defer hashMu.Unlock()
+	hashKey = append([]byte(nil), key...)
+}
+
+//redactingLogger hides the PII fields of the messages before passing them on
+type redactingLogger struct {
+	logger Logger
+	mode   Mode
+	fields []string
+}
+
+//Redacting is used to hide the value of PII fields, in groups too, before they reach a logger.
+//The fields are matched case insensitively. A logger already wrapped with Redacting is returned as is.
+//Input
+//	logger - logger receiving the messages
+//	mode - Hash or Redact
+//	fields - PII field names, DefaultPIIFields when empty
+//Returns
+//	logger hiding the PII fields
+func Redacting(logger Logger, mode Mode, fields ...string) Logger {
+	if _, ok := logger.(*redactingLogger); ok {
+		return logger
+	}
+	if len(fields) == 0 {
+		fields = DefaultPIIFields
+	}
+	return &redactingLogger{logger: logger, mode: mode, fields: fields}
+}
+
+func (r *redactingLogger) Debug(msg string, args ...any) { r.logger.Debug(msg, r.redact(args)...) }
+func (r *redactingLogger) Info(msg string, args ...any)  { r.logger.Info(msg, r.redact(args)...) }
+func (r *redactingLogger) Warn(msg string, args ...any)  { r.logger.Warn(msg, r.redact(args)...) }
+func (r *redactingLogger) Error(msg string, args ...any) { r.logger.Error(msg, r.redact(args)...) }
+
+//Convert the arguments into attributes with the PII fields hidden
+func (r *redactingLogger) redact(args []any) []any {
+	list := attrs(args)
+	redacted := make([]any, len(list))
+	for i, attr := range list {
+		redacted[i] = r.attr(attr)
+	}
+	return redacted
+}
+
+func (r *redactingLogger) attr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	if r.pii(attr.Key) {
+		return slog.String(attr.Key, r.hide(value))
+	}
+	if value.Kind() != slog.KindGroup {
+		return slog.Attr{Key: attr.Key, Value: value}
+	}
+	members := value.Group()
+	redacted := make([]slog.Attr, len(members))
+	for i, member := range members {
+		redacted[i] = r.attr(member)
+	}
+	return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redacted...)}
+}
+
+//Reports whether a key is a PII field
+func (r *redactingLogger) pii(key string) bool {
+	for _, field := range r.fields {
+		if strings.EqualFold(key, field) {
+			return true
+		}
+	}
+	return false
+}
+
+//Hide a PII value, an empty value is kept
+func (r *redactingLogger) hide(value slog.Value) string {
+	s := value.String()
+	if s == "" {
+		return s
+	}
+	if r.mode == Redact {
+		return RedactedValue
+	}
+	hashMu.RLock()
+	mac := hmac.New(sha256.New, hashKey)
+	hashMu.RUnlock()
+	mac.Write([]byte(s))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:16])
+}
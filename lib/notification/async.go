@@ -0,0 +1,227 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package notification
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	logging "github.com/ebay/event-notification-golang-sdk.git/lib/logging"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+const (
+	//DefaultQueueSize is the number of verified notifications waiting for a worker in async mode
+	DefaultQueueSize = 1000
+	//DefaultWorkers is the number of workers processing notifications in async mode
+	DefaultWorkers = 10
+)
+
+//AsyncOption configures the async mode of a Client
+type AsyncOption func(*asyncQueue)
+
+//WithQueueSize sets the number of notifications waiting for a worker, further notifications
+//are rejected with ErrQueueFull. Each topic limited with WithTopicConcurrency has a queue of this size of its own.
+//Default: DefaultQueueSize
+func WithQueueSize(size int) AsyncOption {
+	return func(q *asyncQueue) {
+		q.size = size
+	}
+}
+
+//WithWorkers sets the number of workers of the topics not limited with WithTopicConcurrency. Default: DefaultWorkers
+func WithWorkers(workers int) AsyncOption {
+	return func(q *asyncQueue) {
+		q.workers = workers
+	}
+}
+
+//WithTopicConcurrency limits the number of notifications of a topic processed at once, at least one.
+//The topic gets a queue and limit workers of its own, so a backlog of the topic does not hold up the others.
+func WithTopicConcurrency(topic string, limit int) AsyncOption {
+	return func(q *asyncQueue) {
+		q.limits[topic] = max(limit, 1)
+	}
+}
+
+//WithResultHandler sets a function called with the outcome of each notification processed in async mode.
+//Default: failures are logged
+func WithResultHandler(handler func(*Result, error)) AsyncOption {
+	return func(q *asyncQueue) {
+		q.onResult = handler
+	}
+}
+
+//WithAsync acknowledges notifications once verified and queued, and processes them with a worker pool.
+//Processor failures are no longer reported to eBay, which will not redeliver the notification.
+//With WithOutbox, failed notifications are kept in the outbox until the next Replay.
+//Call Shutdown to stop accepting notifications and drain the queue.
+func WithAsync(opts ...AsyncOption) Option {
+	return func(c *Client) {
+		q := &asyncQueue{size: DefaultQueueSize, workers: DefaultWorkers, limits: make(map[string]int)}
+		for _, opt := range opts {
+			opt(q)
+		}
+		c.async = q
+	}
+}
+
+//asyncQueue is a bounded queue of verified notifications and the workers processing them,
+//with a queue and workers for each limited topic
+type asyncQueue struct {
+	size     int
+	workers  int
+	limits   map[string]int
+	onResult func(*Result, error)
+
+	client  *Client
+	jobs    chan *asyncJob
+	topics  map[string]chan *asyncJob
+	ctx     context.Context
+	cancel  context.CancelFunc
+	mu      sync.RWMutex
+	closed  bool
+	started sync.Once
+	wg      sync.WaitGroup
+}
+
+//asyncJob is a queued notification, its receipt time and outbox entry
+type asyncJob struct {
+	message    *pojo.Message
+	receivedAt time.Time
+	entryID    string
+}
+
+//Start the workers processing notifications for a client
+func (q *asyncQueue) start(client *Client) {
+	q.started.Do(func() {
+		q.client = client
+		q.ctx, q.cancel = context.WithCancel(context.Background())
+		q.jobs = q.spawn(q.workers)
+		q.topics = make(map[string]chan *asyncJob, len(q.limits))
+		for topic, limit := range q.limits {
+			q.topics[topic] = q.spawn(limit)
+		}
+	})
+}
+
+//Start workers processing a new queue
+//Input
+//	workers - number of workers
+//Returns
+//	queue
+func (q *asyncQueue) spawn(workers int) chan *asyncJob {
+	jobs := make(chan *asyncJob, q.size)
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.run(jobs)
+	}
+	return jobs
+}
+
+//Queue a verified notification
+//Input
+//	message - verified message
+//	receivedAt - receipt time of the notification
+//	entryID - outbox entry of the notification, empty without outbox
+//Returns
+//	error matching ErrQueueFull or ErrShuttingDown
+func (q *asyncQueue) enqueue(message *pojo.Message, receivedAt time.Time, entryID string) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrShuttingDown
+	}
+	jobs := q.jobs
+	if topic, ok := q.topics[message.Metadata.Topic]; ok {
+		jobs = topic
+	}
+	select {
+	case jobs <- &asyncJob{message: message, receivedAt: receivedAt, entryID: entryID}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+//Process notifications until their queue is closed and drained
+func (q *asyncQueue) run(jobs <-chan *asyncJob) {
+	defer q.wg.Done()
+	for job := range jobs {
+		result, err := q.client.process(q.ctx, job.message, job.receivedAt)
+		if err == nil {
+			q.client.ack(job.entryID)
+		} else {
+			q.client.leave(job.entryID)
+		}
+
+		if q.onResult != nil {
+			q.onResult(result, err)
+		} else if err != nil {
+			q.client.log().Error("Failed to process notification", logging.KeyTopic, result.Topic, logging.KeyNotificationID, result.NotificationID,
+				logging.KeyOutcome, logging.OutcomeFailed, logging.KeyError, err)
+		}
+	}
+}
+
+//Stop accepting notifications and wait for the queued ones to be processed
+//Input
+//	ctx - deadline of the drain, the context of the processors is canceled when it is done
+//Returns
+//	error of ctx when the queue was not drained in time
+func (q *asyncQueue) shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		if q.jobs != nil {
+			close(q.jobs)
+		}
+		for _, jobs := range q.topics {
+			close(jobs)
+		}
+	}
+	q.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		if q.cancel != nil {
+			q.cancel()
+		}
+		return ctx.Err()
+	}
+}
+
+//Shutdown stops accepting notifications in async mode, rejecting them with ErrShuttingDown,
+//and waits for the queued ones to be processed. Without async mode it returns immediately.
+//Input
+//	ctx - deadline of the drain, the context of the processors is canceled when it is done
+//Returns
+//	error of ctx when the queue was not drained in time
+func (c *Client) Shutdown(ctx context.Context) error {
+	if c.async == nil {
+		return nil
+	}
+	return c.async.shutdown(ctx)
+}
@@ -0,0 +1,470 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	deadletter "github.com/ebay/event-notification-golang-sdk.git/lib/deadletter"
+	dedup "github.com/ebay/event-notification-golang-sdk.git/lib/dedup"
+	errs "github.com/ebay/event-notification-golang-sdk.git/lib/errs"
+	helper "github.com/ebay/event-notification-golang-sdk.git/lib/helper"
+	logging "github.com/ebay/event-notification-golang-sdk.git/lib/logging"
+	outbox "github.com/ebay/event-notification-golang-sdk.git/lib/outbox"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+	service "github.com/ebay/event-notification-golang-sdk.git/lib/service"
+)
+
+//Logger receives the log output of a Client in the format of the log package, *log.Logger satisfies it.
+//Prefer WithStructuredLogger.
+type Logger interface {
+	Println(v ...interface{})
+}
+
+//Client validates and processes notifications for one configuration.
+//Clients are safe for concurrent use and do not share state unless configured to.
+type Client struct {
+	config      *pojo.Config
+	environment string
+	httpClient  *http.Client
+	cache       *service.KeyCache
+	keys        service.KeyProvider
+	tokens      service.TokenSource
+	keyEndpoint string
+	logger      logging.Logger
+	now         func() time.Time
+	registry    *processor.Registry
+
+	maxBodySize    int64
+	requestTimeout time.Duration
+
+	dedup            dedup.Store
+	dedupRetention   time.Duration
+	dedupLockTimeout time.Duration
+
+	async    *asyncQueue
+	outbox   outbox.Store
+	inFlight *inFlight
+
+	retry       RetryPolicy
+	deadLetters deadletter.Store
+
+	deprecationHook DeprecationHook
+	deprecations    *deprecations
+
+	freshness  *FreshnessPolicy
+	signatures dedup.Store
+}
+
+//Option configures a Client
+type Option func(*Client)
+
+//WithEnvironment sets the environment, PRODUCTION or SANDBOX. Default: PRODUCTION
+func WithEnvironment(environment string) Option {
+	return func(c *Client) {
+		c.environment = environment
+	}
+}
+
+//WithHTTPClient sets the client used to call the eBay APIs. Default: a client timing out after
+//service.DefaultHTTPTimeout
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+//WithCache sets the cache of public keys fetched from the notification API.
+//Custom key providers are not cached, wrap them with KeyCache.Provider when needed.
+func WithCache(cache *service.KeyCache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+//WithKeyProvider sets the provider of public keys, replacing the notification API.
+//Client credentials are not required with a custom key provider.
+func WithKeyProvider(keys service.KeyProvider) Option {
+	return func(c *Client) {
+		c.keys = keys
+	}
+}
+
+//WithPublicKeyEndpoint sets the URL prefix of the public key API, e.g. of a local fake server.
+//Default: the notification API of the environment
+func WithPublicKeyEndpoint(endpoint string) Option {
+	return func(c *Client) {
+		c.keyEndpoint = endpoint
+	}
+}
+
+//WithLogger sets a logger in the format of the log package, one line per message with its
+//level and fields. Debug messages are dropped and PII fields hashed. Default: logging.Default
+func WithLogger(logger Logger) Option {
+	return WithStructuredLogger(logging.FromPrinter(logger))
+}
+
+//WithStructuredLogger sets the logger, e.g. a *slog.Logger. PII fields are hashed unless the
+//logger is wrapped with logging.Redacting. Default: logging.Default
+func WithStructuredLogger(logger logging.Logger) Option {
+	return func(c *Client) {
+		c.logger = logging.Redacting(logger, logging.Hash)
+	}
+}
+
+//WithClock sets the function returning the current time. Default: time.Now
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) {
+		c.now = now
+	}
+}
+
+//WithRegistry sets the processor registry. Default: processor.DefaultRegistry
+func WithRegistry(registry *processor.Registry) Option {
+	return func(c *Client) {
+		c.registry = registry
+	}
+}
+
+//WithMaxBodySize sets the largest notification body accepted by the handler. Default: DefaultMaxBodySize
+func WithMaxBodySize(size int64) Option {
+	return func(c *Client) {
+		c.maxBodySize = size
+	}
+}
+
+//WithRequestTimeout sets how long the handler lets a notification be verified and processed,
+//zero disables the timeout. Default: DefaultRequestTimeout
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.requestTimeout = timeout
+	}
+}
+
+//WithDedupStore sets the store deduplicating notifications redelivered by eBay on their notificationId.
+//Default: none, every delivery is processed
+func WithDedupStore(store dedup.Store) Option {
+	return func(c *Client) {
+		c.dedup = store
+	}
+}
+
+//WithDedupRetention sets how long a processed notificationId is remembered. Default: dedup.DefaultRetention
+func WithDedupRetention(retention time.Duration) Option {
+	return func(c *Client) {
+		c.dedupRetention = retention
+	}
+}
+
+//WithDedupLockTimeout sets how long a notificationId stays locked while it is processed,
+//it should exceed the time the processor takes. Default: dedup.DefaultLockTimeout
+func WithDedupLockTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.dedupLockTimeout = timeout
+	}
+}
+
+//defaultClient backs the package level functions
+var defaultClient = &Client{
+	environment: constants.EnvironmentProduction,
+	httpClient:  service.DefaultHTTPClient,
+	cache:       service.DefaultKeyCache,
+	now:         time.Now,
+	registry:    processor.DefaultRegistry,
+
+	maxBodySize:    DefaultMaxBodySize,
+	requestTimeout: DefaultRequestTimeout,
+
+	dedupRetention:   dedup.DefaultRetention,
+	dedupLockTimeout: dedup.DefaultLockTimeout,
+
+	deprecations: newDeprecations(),
+}
+
+//NewClient is used to create a client for a configuration
+//Input
+//	config - config details for processing
+//	opts - options overriding the defaults
+//Returns
+//	client
+//	error matching ErrInvalidConfig
+func NewClient(config *pojo.Config, opts ...Option) (*Client, error) {
+	if config == nil {
+		return nil, &inputError{ErrInvalidConfig, `Please provide the config.`}
+	}
+	client := &Client{
+		config:      config,
+		environment: constants.EnvironmentProduction,
+		httpClient:  &http.Client{Timeout: service.DefaultHTTPTimeout},
+		now:         time.Now,
+		registry:    processor.DefaultRegistry,
+
+		maxBodySize:    DefaultMaxBodySize,
+		requestTimeout: DefaultRequestTimeout,
+
+		dedupRetention:   dedup.DefaultRetention,
+		dedupLockTimeout: dedup.DefaultLockTimeout,
+
+		deprecations: newDeprecations(),
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	if err := validateEnvironment(client.environment); err != nil {
+		return nil, err
+	}
+	if client.cache == nil {
+		cache, err := service.NewKeyCache(service.DefaultCacheSize)
+		if err != nil {
+			return nil, errs.Wrap(ErrInvalidConfig, err)
+		}
+		client.cache = cache
+	}
+	if client.keys == nil {
+		// owned by the client, so its credentials are not kept once it is gone
+		client.tokens = service.NewTokenSource(getEnvironmentConfig(client.config, client.environment), client.httpClient)
+	}
+	if client.async != nil {
+		client.async.start(client)
+	}
+	return client, nil
+}
+
+//Returns a copy of the client using another configuration
+func (c *Client) withConfig(config *pojo.Config, environment string) *Client {
+	client := *c
+	client.config = config
+	client.environment = environment
+	return &client
+}
+
+//Returns the configured logger, or the default logger at the time of the call
+func (c *Client) log() logging.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return logging.Default()
+}
+
+//Returns the fields identifying a notification in the log
+func messageFields(message *pojo.Message, fields ...any) []any {
+	return append([]any{logging.KeyTopic, message.Metadata.Topic, logging.KeyNotificationID, message.Notification.NotificationID}, fields...)
+}
+
+//Returns the configured key provider, or the notification API of the client environment
+func (c *Client) keyProvider() service.KeyProvider {
+	if c.keys != nil {
+		return c.keys
+	}
+	remote := service.NewRemoteKeyProvider(getEnvironmentConfig(c.config, c.environment), c.httpClient)
+	if c.tokens != nil {
+		remote.WithTokenSource(c.tokens)
+	}
+	return c.cache.Provider(remote.WithEndpoint(c.keyEndpoint))
+}
+
+//ValidateAndProcess is to validate the signature of a decoded message and process it.
+//Prefer ValidateAndProcessRaw when the request body is available.
+//Input
+//	ctx - context passed on to the key provider and processor
+//	message - message to be processed
+//	signature - X-EBAY-SIGNATURE header
+//Returns
+//	result of the processing
+//	error matching one of the Err sentinels, use HTTPStatus to get the response code
+func (c *Client) ValidateAndProcess(ctx context.Context, message *pojo.Message, signature string) (*Result, error) {
+	if message == nil {
+		return nil, &inputError{ErrMissingMessage, `Please provide the message.`}
+	}
+	if err := validateInput(signature, c.config, c.environment, c.keys == nil); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, errs.Wrap(ErrInvalidMessage, err)
+	}
+	if err := helper.VerifyRaw(ctx, body, signature, c.keyProvider()); err != nil {
+		return nil, err
+	}
+	if err := c.checkReplay(ctx, message, signature); err != nil {
+		return nil, err
+	}
+	return c.handle(ctx, message, body, signature)
+}
+
+//ValidateAndProcessRaw is to validate the signature of the raw request body, then decode and process it
+//Input
+//	ctx - context passed on to the key provider and processor
+//	body - raw request body
+//	signature - X-EBAY-SIGNATURE header
+//Returns
+//	result of the processing
+//	error matching one of the Err sentinels, use HTTPStatus to get the response code
+func (c *Client) ValidateAndProcessRaw(ctx context.Context, body []byte, signature string) (*Result, error) {
+	if len(body) == 0 {
+		return nil, &inputError{ErrMissingMessage, `Please provide the message.`}
+	}
+	if err := validateInput(signature, c.config, c.environment, c.keys == nil); err != nil {
+		return nil, err
+	}
+
+	if err := helper.VerifyRaw(ctx, body, signature, c.keyProvider()); err != nil {
+		return nil, err
+	}
+
+	var message pojo.Message
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, errs.Wrap(ErrInvalidMessage, err)
+	}
+	if err := c.checkReplay(ctx, &message, signature); err != nil {
+		return nil, err
+	}
+	return c.handle(ctx, &message, body, signature)
+}
+
+//ChallengeResponse is to compute the challenge response validating the endpoint
+//Input
+//	challengeCode - challengeCode to be processed
+//Returns
+//	challenge response
+//	error matching ErrMissingChallengeCode or ErrInvalidConfig
+func (c *Client) ChallengeResponse(challengeCode string) (string, error) {
+	if err := validateChallenge(challengeCode, c.config); err != nil {
+		return "", err
+	}
+	return helper.GenerateChallengeResponse(challengeCode, c.config), nil
+}
+
+//Persist a verified message to the outbox when set, then process it, or queue it in async mode
+//Input
+//	ctx - context passed on to the processor
+//	message - verified message
+//	body - verified body
+//	signature - X-EBAY-SIGNATURE header
+//Returns
+//	result of the processing
+//	error matching one of the Err sentinels
+func (c *Client) handle(ctx context.Context, message *pojo.Message, body []byte, signature string) (*Result, error) {
+	receivedAt := c.now()
+	if message.Metadata.Deprecated {
+		c.deprecated(ctx, message, receivedAt)
+	}
+	entryID, err := c.persist(ctx, body, signature, receivedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.async == nil {
+		result, err := c.process(ctx, message, receivedAt)
+		// eBay redelivers a failed notification, so the entry is not kept
+		c.ack(entryID)
+		return result, err
+	}
+	result := &Result{Topic: message.Metadata.Topic, NotificationID: message.Notification.NotificationID, ReceivedAt: receivedAt}
+	if err := c.async.enqueue(message, receivedAt, entryID); err != nil {
+		c.ack(entryID)
+		return result, err
+	}
+	result.Queued = true
+	return result, nil
+}
+
+//Process a verified message once, skipping notifications already processed when a dedup store is set
+//Input
+//	ctx - context passed on to the processor
+//	message - verified message
+//	receivedAt - receipt time of the notification
+//Returns
+//	result of the processing
+//	error matching ErrUnknownTopic, ErrProcessorFailed, ErrInProgress or ErrDedupFailed
+func (c *Client) process(ctx context.Context, message *pojo.Message, receivedAt time.Time) (*Result, error) {
+	result := &Result{Topic: message.Metadata.Topic, NotificationID: message.Notification.NotificationID, ReceivedAt: receivedAt}
+	id := message.Notification.NotificationID
+	if c.dedup == nil || id == "" {
+		return result, c.dispatch(ctx, message, result)
+	}
+
+	state, token, err := c.dedup.Acquire(ctx, id, c.dedupLockTimeout)
+	if err != nil {
+		return result, errs.Wrap(ErrDedupFailed, err)
+	}
+	switch state {
+	case dedup.Done:
+		result.Duplicate = true
+		return result, nil
+	case dedup.InProgress:
+		return result, errs.Wrap(ErrInProgress, fmt.Errorf("notification %s", id))
+	}
+
+	// the outcome is recorded even when the request context is done
+	if err := c.dispatch(ctx, message, result); err != nil {
+		if releaseErr := c.dedup.Release(context.Background(), id, token); releaseErr != nil {
+			c.log().Error("Failed to release notification", messageFields(message, logging.KeyError, releaseErr)...)
+		}
+		return result, err
+	}
+	if err := c.dedup.Complete(context.Background(), id, token, c.dedupRetention); err != nil {
+		// processed, a redelivery after the lock timeout is processed again
+		c.log().Error("Failed to complete notification", messageFields(message, logging.KeyError, err)...)
+	}
+	return result, nil
+}
+
+//Dispatch a verified message to the processor registered for its topic, retrying failures
+//according to the retry policy and parking them in the dead letter store when set
+//Input
+//	ctx - context passed on to the processor
+//	message - verified message
+//	result - result to mark as dropped or dead lettered
+//Returns
+//	error matching ErrUnknownTopic or ErrProcessorFailed
+func (c *Client) dispatch(ctx context.Context, message *pojo.Message, result *Result) error {
+	obj, ok := c.registry.Resolve(message)
+	if !ok {
+		return errs.Wrap(ErrUnknownTopic, fmt.Errorf("topic %s schema version %s", message.Metadata.Topic, message.Metadata.SchemaVersion))
+	}
+	attempts, err := c.execute(ctx, obj, message)
+	if err == nil {
+		return nil
+	}
+	if c.deadLetters != nil {
+		letter, putErr := c.bury(message, result.ReceivedAt, attempts, err)
+		if putErr == nil {
+			c.log().Warn("Dead lettering notification", messageFields(message, "deadLetter", letter.ID,
+				logging.KeyAttempt, len(attempts), logging.KeyOutcome, logging.OutcomeDeadLettered, logging.KeyError, err)...)
+			result.DeadLetter = letter.ID
+			result.Err = err
+			return nil
+		}
+		c.log().Error("Failed to dead letter notification", messageFields(message, logging.KeyError, putErr)...)
+	}
+	if !processor.IsPermanent(err) {
+		return errs.Wrap(ErrProcessorFailed, err)
+	}
+	c.log().Warn("Dropping notification", messageFields(message, logging.KeyAttempt, len(attempts),
+		logging.KeyOutcome, logging.OutcomeDropped, logging.KeyError, err)...)
+	result.Dropped = true
+	result.Err = err
+	return nil
+}
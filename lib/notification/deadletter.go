@@ -0,0 +1,161 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	deadletter "github.com/ebay/event-notification-golang-sdk.git/lib/deadletter"
+	errs "github.com/ebay/event-notification-golang-sdk.git/lib/errs"
+	logging "github.com/ebay/event-notification-golang-sdk.git/lib/logging"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//WithDeadLetterStore parks notifications whose processor failed after all retries, or with a
+//permanent error, in a dead letter store. They are acknowledged to eBay, which stops redelivering
+//them, and can be inspected, redriven or discarded with the DeadLetter methods of the Client.
+//Dead letters keep the whole message so it can be redriven, including the PII of the payload,
+//e.g. the username and userId of an account deletion: deadletter.FileStore writes it unredacted
+//to disk, so restrict access to its directory and discard dead letters once handled.
+func WithDeadLetterStore(store deadletter.Store) Option {
+	return func(c *Client) {
+		c.deadLetters = store
+	}
+}
+
+//Store a failed notification as a dead letter
+//Input
+//	message - verified message
+//	receivedAt - receipt time of the notification
+//	attempts - history of the failed attempts
+//	err - error of the last attempt
+//Returns
+//	dead letter
+//	error of the store
+func (c *Client) bury(message *pojo.Message, receivedAt time.Time, attempts []deadletter.Attempt, err error) (*deadletter.Letter, error) {
+	id, idErr := deadletter.NewID()
+	if idErr != nil {
+		return nil, idErr
+	}
+	letter := &deadletter.Letter{
+		ID:             id,
+		Topic:          message.Metadata.Topic,
+		NotificationID: message.Notification.NotificationID,
+		Message:        message,
+		Error:          err.Error(),
+		Attempts:       attempts,
+		ReceivedAt:     receivedAt,
+		DeadAt:         c.now(),
+	}
+	// not bound to the request, which may already be done
+	return letter, c.deadLetters.Put(context.Background(), letter)
+}
+
+//Returns the dead letter store, or an error when none is set
+func (c *Client) deadLetterStore() (deadletter.Store, error) {
+	if c.deadLetters == nil {
+		return nil, errs.Wrap(ErrInvalidConfig, errors.New("no dead letter store"))
+	}
+	return c.deadLetters, nil
+}
+
+//DeadLetters is used to list the dead letters
+//Input
+//	ctx - context of the store
+//Returns
+//	dead letters, oldest first
+//	error matching ErrInvalidConfig without dead letter store, or the error of the store
+func (c *Client) DeadLetters(ctx context.Context) ([]*deadletter.Letter, error) {
+	store, err := c.deadLetterStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.List(ctx)
+}
+
+//DeadLetter is used to inspect a dead letter
+//Input
+//	ctx - context of the store
+//	id - dead letter id
+//Returns
+//	dead letter
+//	error matching deadletter.ErrNotFound or ErrInvalidConfig, or the error of the store
+func (c *Client) DeadLetter(ctx context.Context, id string) (*deadletter.Letter, error) {
+	store, err := c.deadLetterStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Get(ctx, id)
+}
+
+//Redrive is used to process a dead letter again with the retry policy, e.g. once the cause
+//of the failure is fixed. The dedup store is bypassed. The dead letter is removed when processed,
+//otherwise the new attempts are added to its history.
+//Input
+//	ctx - context passed on to the processor
+//	id - dead letter id
+//Returns
+//	result of the processing
+//	error matching ErrUnknownTopic, ErrProcessorFailed, ErrInvalidDeadLetter, deadletter.ErrNotFound or ErrInvalidConfig
+func (c *Client) Redrive(ctx context.Context, id string) (*Result, error) {
+	letter, err := c.DeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Topic: letter.Topic, NotificationID: letter.NotificationID, ReceivedAt: letter.ReceivedAt, DeadLetter: letter.ID}
+	if letter.Message == nil {
+		// e.g. a dead letter file edited by hand
+		return result, errs.Wrap(ErrInvalidDeadLetter, fmt.Errorf("dead letter %s has no message", letter.ID))
+	}
+	obj, ok := c.registry.Resolve(letter.Message)
+	if !ok {
+		return result, errs.Wrap(ErrUnknownTopic, fmt.Errorf("topic %s schema version %s", letter.Topic, letter.Message.Metadata.SchemaVersion))
+	}
+
+	attempts, err := c.execute(ctx, obj, letter.Message)
+	if err != nil {
+		previous := len(letter.Attempts)
+		for _, attempt := range attempts {
+			attempt.Number += previous
+			letter.Attempts = append(letter.Attempts, attempt)
+		}
+		letter.Error = err.Error()
+		letter.DeadAt = c.now()
+		if putErr := c.deadLetters.Put(context.Background(), letter); putErr != nil {
+			c.log().Error("Failed to update dead letter", messageFields(letter.Message, "deadLetter", letter.ID, logging.KeyError, putErr)...)
+		}
+		result.Err = err
+		return result, errs.Wrap(ErrProcessorFailed, err)
+	}
+	return result, c.deadLetters.Delete(context.Background(), letter.ID)
+}
+
+//Discard is used to remove a dead letter without processing it
+//Input
+//	ctx - context of the store
+//	id - dead letter id
+//Returns
+//	error matching ErrInvalidConfig, or the error of the store
+func (c *Client) Discard(ctx context.Context, id string) error {
+	store, err := c.deadLetterStore()
+	if err != nil {
+		return err
+	}
+	return store.Delete(ctx, id)
+}
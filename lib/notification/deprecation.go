@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+package notification
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//DeprecationHook is called with each verified notification marked deprecated by eBay,
+//before it is processed
+type DeprecationHook func(ctx context.Context, message *pojo.Message)
+
+//Deprecation counts the notifications received for a deprecated schema version of a topic
+type Deprecation struct {
+	Topic         string
+	SchemaVersion string
+	Count         uint64
+	FirstSeen     time.Time
+	LastSeen      time.Time
+}
+
+//WithDeprecationHook sets a hook called with notifications marked deprecated, e.g. to alert before
+//eBay removes the schema version. A warning is logged for the first one of each topic and version
+//either way.
+func WithDeprecationHook(hook DeprecationHook) Option {
+	return func(c *Client) {
+		c.deprecationHook = hook
+	}
+}
+
+//deprecations are the deprecated schema versions received by a client
+type deprecations struct {
+	mu     sync.Mutex
+	counts map[deprecationKey]*Deprecation
+}
+
+type deprecationKey struct {
+	topic, version string
+}
+
+func newDeprecations() *deprecations {
+	return &deprecations{counts: make(map[deprecationKey]*Deprecation)}
+}
+
+//Count a notification, returns true for the first one of its topic and version
+func (d *deprecations) record(message *pojo.Message, receivedAt time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := deprecationKey{message.Metadata.Topic, message.Metadata.SchemaVersion}
+	deprecation, ok := d.counts[key]
+	if !ok {
+		deprecation = &Deprecation{Topic: key.topic, SchemaVersion: key.version, FirstSeen: receivedAt}
+		d.counts[key] = deprecation
+	}
+	deprecation.Count++
+	deprecation.LastSeen = receivedAt
+	return !ok
+}
+
+//Record a notification marked deprecated, warn about it and call the hook
+func (c *Client) deprecated(ctx context.Context, message *pojo.Message, receivedAt time.Time) {
+	if c.deprecations.record(message, receivedAt) {
+		c.log().Warn("Received notification with deprecated schema version, upgrade before it is removed",
+			messageFields(message, "schemaVersion", message.Metadata.SchemaVersion)...)
+	}
+	if c.deprecationHook != nil {
+		c.deprecationHook(ctx, message)
+	}
+}
+
+//Deprecations is used to get the deprecated schema versions received by the client
+//Returns
+//	counters sorted by topic and schema version
+func (c *Client) Deprecations() []Deprecation {
+	c.deprecations.mu.Lock()
+	defer c.deprecations.mu.Unlock()
+	list := make([]Deprecation, 0, len(c.deprecations.counts))
+	for _, deprecation := range c.deprecations.counts {
+		list = append(list, *deprecation)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Topic != list[j].Topic {
+			return list[i].Topic < list[j].Topic
+		}
+		return list[i].SchemaVersion < list[j].SchemaVersion
+	})
+	return list
+}
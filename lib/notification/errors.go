@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package notification
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	errs "github.com/ebay/event-notification-golang-sdk.git/lib/errs"
+	logging "github.com/ebay/event-notification-golang-sdk.git/lib/logging"
+)
+
+//Errors returned by ValidateAndProcessContext, ValidateAndProcessRaw and ChallengeResponse,
+//to be matched with errors.Is
+var (
+	ErrMissingMessage       = errs.ErrMissingMessage
+	ErrInvalidMessage       = errs.ErrInvalidMessage
+	ErrMissingSignature     = errs.ErrMissingSignature
+	ErrInvalidSignature     = errs.ErrInvalidSignature
+	ErrInvalidConfig        = errs.ErrInvalidConfig
+	ErrKeyFetchFailed       = errs.ErrKeyFetchFailed
+	ErrUnknownTopic         = errs.ErrUnknownTopic
+	ErrProcessorFailed      = errs.ErrProcessorFailed
+	ErrMissingChallengeCode = errs.ErrMissingChallengeCode
+	ErrInProgress           = errs.ErrInProgress
+	ErrDedupFailed          = errs.ErrDedupFailed
+	ErrQueueFull            = errs.ErrQueueFull
+	ErrShuttingDown         = errs.ErrShuttingDown
+	ErrOutboxFailed         = errs.ErrOutboxFailed
+	ErrStaleMessage         = errs.ErrStaleMessage
+	ErrReplayedMessage      = errs.ErrReplayedMessage
+	ErrInvalidDeadLetter    = errs.ErrInvalidDeadLetter
+)
+
+//Result is the outcome of a successfully validated notification
+type Result struct {
+	Topic          string
+	NotificationID string
+	ReceivedAt     time.Time
+	//Dropped is set when the processor failed with a permanent error
+	Dropped bool
+	//Err is the permanent processor error of a dropped notification
+	Err error
+	//Duplicate is set when the notification was already processed and the processor was skipped
+	Duplicate bool
+	//Queued is set in async mode when the notification was queued for processing
+	Queued bool
+	//DeadLetter is the id of the dead letter parked after the processor kept failing
+	DeadLetter string
+}
+
+//Outcome is used to get the outcome of the notification as logged with logging.KeyOutcome
+func (r *Result) Outcome() string {
+	switch {
+	case r.DeadLetter != "":
+		return logging.OutcomeDeadLettered
+	case r.Dropped:
+		return logging.OutcomeDropped
+	case r.Duplicate:
+		return logging.OutcomeDuplicate
+	case r.Queued:
+		return logging.OutcomeQueued
+	}
+	return logging.OutcomeProcessed
+}
+
+//HTTPStatus returns the status code to acknowledge the notification with
+func (r *Result) HTTPStatus() int {
+	return http.StatusNoContent
+}
+
+//HTTPStatus is used to map an error to the HTTP status code eBay expects
+//Input
+//	err - error returned by the SDK, nil on success
+//Returns
+//	HTTP status code
+func HTTPStatus(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusNoContent
+	case errors.Is(err, ErrMissingSignature), errors.Is(err, ErrInvalidSignature),
+		errors.Is(err, ErrStaleMessage), errors.Is(err, ErrReplayedMessage):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrMissingMessage), errors.Is(err, ErrInvalidMessage), errors.Is(err, ErrMissingChallengeCode):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrInProgress):
+		return http.StatusConflict
+	case errors.Is(err, ErrQueueFull):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrShuttingDown):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+//inputError is a validation error keeping the message returned by the string based API
+type inputError struct {
+	kind    error
+	message string
+}
+
+func (e *inputError) Error() string {
+	return e.message
+}
+
+func (e *inputError) Is(target error) bool {
+	return target == e.kind
+}
+
+//Convert a result and error to the error and response strings returned by ValidateAndProcess
+func legacyResponse(result *Result, err error) (string, string) {
+	var input *inputError
+	if err == nil {
+		return "", constants.HTTPStatusCodeNoContent
+	} else if errors.As(err, &input) {
+		return input.message, ""
+	} else if errors.Is(err, ErrInvalidSignature) || errors.Is(err, ErrStaleMessage) || errors.Is(err, ErrReplayedMessage) {
+		return constants.HTTPStatusCodePreconditionFailed, ""
+	}
+	return constants.HTTPStatusCodeInternalServerError, ""
+}
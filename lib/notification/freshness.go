@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+package notification
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	dedup "github.com/ebay/event-notification-golang-sdk.git/lib/dedup"
+	errs "github.com/ebay/event-notification-golang-sdk.git/lib/errs"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//signatureKeyPrefix keeps seen signatures apart from notification ids in a shared dedup store
+const signatureKeyPrefix = "signature:"
+
+//FreshnessPolicy configures how old a notification may be, so a captured notification
+//cannot be replayed once the window has passed
+type FreshnessPolicy struct {
+	//MaxAge is how long after its publishDate a notification is accepted
+	MaxAge time.Duration
+	//MaxSkew is how far the publishDate and eventDate may be in the future, allowing for clock drift
+	MaxSkew time.Duration
+	//RetryAllowance extends MaxAge for each publish attempt after the first one,
+	//as eBay redelivers failed notifications for hours
+	RetryAllowance time.Duration
+}
+
+//DefaultFreshnessPolicy accepts notifications published up to 15 minutes ago, one more hour per
+//redelivery, and up to a minute in the future
+var DefaultFreshnessPolicy = FreshnessPolicy{
+	MaxAge:         15 * time.Minute,
+	MaxSkew:        time.Minute,
+	RetryAllowance: time.Hour,
+}
+
+//WithReplayProtection rejects verified notifications published outside the freshness window with
+//ErrStaleMessage, and notifications whose signature was already seen within it with ErrReplayedMessage.
+//Seen signatures are kept in memory unless WithSignatureStore is set. Default: disabled
+func WithReplayProtection(policy FreshnessPolicy) Option {
+	return func(c *Client) {
+		c.freshness = &policy
+		if c.signatures == nil {
+			c.signatures = dedup.NewMemoryStore(dedup.WithClock(func() time.Time { return c.now() }))
+		}
+	}
+}
+
+//WithSignatureStore sets the store remembering the signatures seen within the freshness window,
+//e.g. a store shared by all instances. It may be the dedup store.
+func WithSignatureStore(store dedup.Store) Option {
+	return func(c *Client) {
+		c.signatures = store
+	}
+}
+
+//Returns how long after its publishDate a notification of the publish attempt is accepted
+func (p FreshnessPolicy) maxAge(attempts int) time.Duration {
+	if attempts > 1 {
+		return p.MaxAge + time.Duration(attempts-1)*p.RetryAllowance
+	}
+	return p.MaxAge
+}
+
+//Reject a verified notification published outside the freshness window or replayed within it
+//Input
+//	ctx - context of the signature store
+//	message - verified message
+//	signature - X-EBAY-SIGNATURE header
+//Returns
+//	error matching ErrStaleMessage, ErrReplayedMessage or ErrDedupFailed
+func (c *Client) checkReplay(ctx context.Context, message *pojo.Message, signature string) error {
+	if c.freshness == nil {
+		return nil
+	}
+	now := c.now()
+	publishDate, err := time.Parse(time.RFC3339, message.Notification.PublishDate)
+	if err != nil {
+		return errs.Wrap(ErrStaleMessage, fmt.Errorf("publishDate: %w", err))
+	}
+	maxAge := c.freshness.maxAge(message.Notification.PublishAttemptCount)
+	if age := now.Sub(publishDate); age > maxAge {
+		return errs.Wrap(ErrStaleMessage, fmt.Errorf("published %s ago, attempt %d accepted for %s", age.Round(time.Second), message.Notification.PublishAttemptCount, maxAge))
+	}
+	if publishDate.Sub(now) > c.freshness.MaxSkew {
+		return errs.Wrap(ErrStaleMessage, fmt.Errorf("published in the future at %s", message.Notification.PublishDate))
+	}
+	if eventDate, err := time.Parse(time.RFC3339, message.Notification.EventDate); err == nil && eventDate.Sub(now) > c.freshness.MaxSkew {
+		return errs.Wrap(ErrStaleMessage, fmt.Errorf("event in the future at %s", message.Notification.EventDate))
+	}
+
+	// a signature is worthless once its notification is stale, so it is forgotten then
+	retention := publishDate.Add(maxAge).Sub(now) + c.freshness.MaxSkew
+	sum := sha256.Sum256([]byte(signature))
+	key := signatureKeyPrefix + hex.EncodeToString(sum[:])
+	state, token, err := c.signatures.Acquire(ctx, key, retention)
+	if err != nil {
+		return errs.Wrap(ErrDedupFailed, err)
+	}
+	if state != dedup.Acquired {
+		return errs.Wrap(ErrReplayedMessage, fmt.Errorf("notification %s", message.Notification.NotificationID))
+	}
+	if err := c.signatures.Complete(ctx, key, token, retention); err != nil {
+		return errs.Wrap(ErrDedupFailed, err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	helper "github.com/ebay/event-notification-golang-sdk.git/lib/helper"
+	logging "github.com/ebay/event-notification-golang-sdk.git/lib/logging"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+const (
+	//DefaultMaxBodySize is the largest notification body accepted by the handler
+	DefaultMaxBodySize = 1 << 20
+	//DefaultRequestTimeout is how long the handler lets a notification be verified and processed
+	DefaultRequestTimeout = 30 * time.Second
+
+	//ChallengeCodeParam is the query parameter carrying the challenge code
+	ChallengeCodeParam = "challenge_code"
+)
+
+//challengeResponse is the body answering the endpoint validation request
+type challengeResponse struct {
+	ChallengeResponse string `json:"challengeResponse"`
+}
+
+//webhookHandler serves the endpoint validation and notification requests of a client
+type webhookHandler struct {
+	client *Client
+}
+
+//NewHandler returns an http.Handler serving the eBay webhook endpoint.
+//GET requests answer the endpoint validation challenge, POST requests are verified
+//against the raw request body before being decoded into a pojo.Message and handed
+//to the registered processor.
+//The handler is backed by a client created with NewClient, so it has a key cache, deprecation
+//counters and OAuth token source of its own.
+//Input
+//	config - config details for processing
+//	opts - options of NewClient, e.g. WithEnvironment, WithMaxBodySize or WithRequestTimeout.
+//	Use a Client with WithAsync to drain the queue on shutdown.
+//Returns
+//	webhook handler
+//	error matching ErrInvalidConfig or ErrInvalidEnvironment, as returned by NewClient
+func NewHandler(config *pojo.Config, opts ...Option) (http.Handler, error) {
+	client, err := NewClient(config, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return client.Handler(), nil
+}
+
+//Handler is like NewHandler but panics when the configuration is invalid, so a misconfigured
+//endpoint fails when the server starts rather than on every request
+//Input
+//	config - config details for processing
+//	opts - options of NewClient
+//Returns
+//	webhook handler
+func Handler(config *pojo.Config, opts ...Option) http.Handler {
+	handler, err := NewHandler(config, opts...)
+	if err != nil {
+		panic("notification: " + err.Error())
+	}
+	return handler
+}
+
+//Handler returns an http.Handler serving the eBay webhook endpoint using this client
+func (c *Client) Handler() http.Handler {
+	return &webhookHandler{client: c}
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.challenge(w, r)
+	case http.MethodPost:
+		h.notification(w, r)
+	default:
+		w.Header().Set("Allow", strings.Join([]string{http.MethodGet, http.MethodPost}, ", "))
+		writeError(w, http.StatusMethodNotAllowed)
+	}
+}
+
+//Answer the endpoint validation challenge
+func (h *webhookHandler) challenge(w http.ResponseWriter, r *http.Request) {
+	response, err := h.client.ChallengeResponse(r.URL.Query().Get(ChallengeCodeParam))
+	if err != nil {
+		h.client.log().Warn("Rejected endpoint validation", logging.KeyOutcome, logging.OutcomeRejected, logging.KeyError, err)
+		writeError(w, HTTPStatus(err))
+		return
+	}
+	body, err := json.Marshal(challengeResponse{ChallengeResponse: response})
+	if err != nil {
+		h.client.log().Error("Failed to answer endpoint validation", logging.KeyError, err)
+		writeError(w, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(constants.ContentType, "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+//Verify and process a notification
+func (h *webhookHandler) notification(w http.ResponseWriter, r *http.Request) {
+	if contentType := r.Header.Get(constants.ContentType); contentType != "" {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+
+	var reader io.Reader = r.Body
+	if h.client.maxBodySize > 0 {
+		// read one byte past the limit to tell a body of exactly the limit from a larger one
+		reader = io.LimitReader(r.Body, h.client.maxBodySize+1)
+	}
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		h.client.log().Warn("Failed to read notification", logging.KeyOutcome, logging.OutcomeRejected, logging.KeyError, err)
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+	if h.client.maxBodySize > 0 && int64(len(body)) > h.client.maxBodySize {
+		writeError(w, http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	ctx := r.Context()
+	if h.client.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.client.requestTimeout)
+		defer cancel()
+	}
+
+	signature := r.Header.Get(constants.XEbaySignature)
+	result, err := h.client.ValidateAndProcessRaw(ctx, body, signature)
+	h.logOutcome(result, err, signature)
+	if err != nil {
+		writeError(w, HTTPStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//Log the outcome of a notification request, rejected ones as warnings and failed ones as errors
+func (h *webhookHandler) logOutcome(result *Result, err error, signature string) {
+	var fields []any
+	if result != nil {
+		fields = append(fields, logging.KeyTopic, result.Topic, logging.KeyNotificationID, result.NotificationID)
+	}
+	if header, decodeErr := helper.DecodeSignatureHeader(signature); decodeErr == nil {
+		fields = append(fields, logging.KeyKid, header.Kid)
+	}
+	status := HTTPStatus(err)
+	switch {
+	case err == nil:
+		h.client.log().Debug("Handled notification", append(fields, logging.KeyOutcome, result.Outcome())...)
+	case status >= http.StatusInternalServerError:
+		h.client.log().Error("Failed to handle notification", append(fields, logging.KeyOutcome, logging.OutcomeFailed, "status", status, logging.KeyError, err)...)
+	default:
+		h.client.log().Warn("Rejected notification", append(fields, logging.KeyOutcome, logging.OutcomeRejected, "status", status, logging.KeyError, err)...)
+	}
+}
+
+//Write an error response with the status text as plain text body
+func writeError(w http.ResponseWriter, status int) {
+	http.Error(w, http.StatusText(status), status)
+}
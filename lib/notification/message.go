@@ -19,11 +19,11 @@ This package implement two methods required for Event Notification Processing
 package notification
 
 import (
+	"context"
+	"strings"
+
 	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
-	helper "github.com/ebay/event-notification-golang-sdk.git/lib/helper"
 	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
-	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
-	"strings"
 )
 
 //Returns CustomEnv object
@@ -33,7 +33,14 @@ import (
 //Returns
 //	customEnvironment - details of specified env
 func getCustomEnv(env *pojo.Environment, environment string) *pojo.CustomEnvironment {
-	return &pojo.CustomEnvironment{env.BaseURL, env.RedirectURI, env.ClientID, env.ClientSecret, env.DevID, environment}
+	return &pojo.CustomEnvironment{
+		BaseURL:      env.BaseURL,
+		RedirectURI:  env.RedirectURI,
+		ClientID:     env.ClientID,
+		ClientSecret: env.ClientSecret,
+		DevID:        env.DevID,
+		Environment:  environment,
+	}
 }
 
 //ValidateAndProcess is to validate request and process the message
@@ -46,39 +53,85 @@ func getCustomEnv(env *pojo.Environment, environment string) *pojo.CustomEnviron
 //	error
 //	response body
 func ValidateAndProcess(message *pojo.Message, signature string, config *pojo.Config, environment string) (string, string) {
-	var err string
-	if message == nil || &message.Metadata == nil || &message.Notification == nil {
-		err = `Please provide the message.`
-	} else if signature == "" {
-		err = `Please provide the signature.`
+	return legacyResponse(ValidateAndProcessContext(context.Background(), message, signature, config, environment))
+}
+
+//ValidateAndProcessContext is to validate request and process the message with a context aware processor.
+//A failing processor is reported with ErrProcessorFailed so eBay redelivers the notification,
+//unless its error is marked with processor.Permanent, in which case the notification is dropped.
+//Input
+//	ctx - context passed on to the processor
+//	message - message to be processed
+//	signature - signature of sender
+//	config - config details for processing
+//	environment - environment name
+//Returns
+//	result of the processing
+//	error matching one of the Err sentinels, use HTTPStatus to get the response code
+func ValidateAndProcessContext(ctx context.Context, message *pojo.Message, signature string, config *pojo.Config, environment string) (*Result, error) {
+	return defaultClient.withConfig(config, environment).ValidateAndProcess(ctx, message, signature)
+}
+
+//ValidateAndProcessRaw is to validate the raw request body and process the message.
+//The signature is verified against the body exactly as received from eBay, and only then
+//decoded into a message, so unknown fields or key ordering do not break verification.
+//Input
+//	ctx - context passed on to the processor
+//	body - raw request body
+//	signature - signature of sender
+//	config - config details for processing
+//	environment - environment name
+//Returns
+//	result of the processing
+//	error matching one of the Err sentinels, use HTTPStatus to get the response code
+func ValidateAndProcessRaw(ctx context.Context, body []byte, signature string, config *pojo.Config, environment string) (*Result, error) {
+	return defaultClient.withConfig(config, environment).ValidateAndProcessRaw(ctx, body, signature)
+}
+
+//Validate the signature and config required to verify a message
+//Input
+//	signature - signature of sender
+//	config - config details for processing
+//	environment - environment name
+//	credentials - whether client credentials are needed to fetch public keys
+//Returns
+//	error, nil when the input is valid
+func validateInput(signature string, config *pojo.Config, environment string, credentials bool) error {
+	if signature == "" {
+		return &inputError{ErrMissingSignature, `Please provide the signature.`}
 	} else if config == nil {
-		err = `Please provide the config.`
-	} else if config.Production.ClientID == "" || config.Sandbox.ClientID == "" {
-		err = `Please provide the Client ID.`
-	} else if config.Production.ClientSecret == "" || config.Sandbox.ClientSecret == "" {
-		err = `Please provide the Client secret.`
-	} else if len(environment) == 0 || (environment != constants.EnvironmentProduction && environment != constants.EnvironmentSandbox) {
-		err = `Please provide the Environment.`
+		return &inputError{ErrInvalidConfig, `Please provide the config.`}
+	} else if credentials && (config.Production.ClientID == "" || config.Sandbox.ClientID == "") {
+		return &inputError{ErrInvalidConfig, `Please provide the Client ID.`}
+	} else if credentials && (config.Production.ClientSecret == "" || config.Sandbox.ClientSecret == "") {
+		return &inputError{ErrInvalidConfig, `Please provide the Client secret.`}
 	}
+	return validateEnvironment(environment)
+}
 
-	if !strings.EqualFold(err, "") {
-		return err, ""
-	}
-	var customEnv *pojo.CustomEnvironment
-	if environment == constants.EnvironmentSandbox {
-		customEnv = getCustomEnv(&config.Sandbox, environment)
-	} else {
-		customEnv = getCustomEnv(&config.Production, environment)
+//Validate the environment name
+//Input
+//	environment - environment name
+//Returns
+//	error, nil when the environment is PRODUCTION or SANDBOX
+func validateEnvironment(environment string) error {
+	if len(environment) == 0 || (environment != constants.EnvironmentProduction && environment != constants.EnvironmentSandbox) {
+		return &inputError{ErrInvalidConfig, `Please provide the Environment.`}
 	}
+	return nil
+}
 
-	response := helper.ValidateSignature(message, signature, customEnv)
-	if strings.EqualFold(response, constants.Success) {
-		processor.GetProcessor(message.Metadata.Topic).Process(message);
-		return "", constants.HTTPStatusCodeNoContent
-	} else if strings.EqualFold(response, constants.Error) {
-		return constants.HTTPStatusCodePreconditionFailed, ""
+//Returns the CustomEnv object of the selected environment
+//Input
+//	config - config details for processing
+//	environment - environment name
+//Returns
+//	customEnvironment - details of specified env
+func getEnvironmentConfig(config *pojo.Config, environment string) *pojo.CustomEnvironment {
+	if environment == constants.EnvironmentSandbox {
+		return getCustomEnv(&config.Sandbox, environment)
 	}
-	return constants.HTTPStatusCodeInternalServerError, ""
+	return getCustomEnv(&config.Production, environment)
 }
 
 //ValidateEndpoint is to validate endpoint using challengeCode
@@ -89,20 +142,39 @@ func ValidateAndProcess(message *pojo.Message, signature string, config *pojo.Co
 //	error
 //	challenge response
 func ValidateEndpoint(challengeCode string, config *pojo.Config) (string, string) {
-	var err string
+	challengeResponse, err := ChallengeResponse(challengeCode, config)
+	if err != nil {
+		return err.Error(), ""
+	}
+	return "", challengeResponse
+}
+
+//ChallengeResponse is to compute the challenge response validating the endpoint
+//Input
+//	challengeCode - challengeCode to be processed
+//	config - config details for processing
+//Returns
+//	challenge response
+//	error matching ErrMissingChallengeCode or ErrInvalidConfig
+func ChallengeResponse(challengeCode string, config *pojo.Config) (string, error) {
+	return defaultClient.withConfig(config, constants.EnvironmentProduction).ChallengeResponse(challengeCode)
+}
+
+//Validate the challenge code and config required to compute the challenge response
+//Input
+//	challengeCode - challengeCode to be processed
+//	config - config details for processing
+//Returns
+//	error, nil when the input is valid
+func validateChallenge(challengeCode string, config *pojo.Config) error {
 	if strings.EqualFold(challengeCode, "") {
-		err = `The "challengeCode" is required.`
+		return &inputError{ErrMissingChallengeCode, `The "challengeCode" is required.`}
 	} else if config == nil {
-		err = `Please provide the config.`
+		return &inputError{ErrInvalidConfig, `Please provide the config.`}
 	} else if strings.EqualFold(config.Endpoint, "") {
-		err = `The "endpoint" is required.`
+		return &inputError{ErrInvalidConfig, `The "endpoint" is required.`}
 	} else if strings.EqualFold(config.VerificationToken, "") {
-		err = `The "verificationToken" is required.`
+		return &inputError{ErrInvalidConfig, `The "verificationToken" is required.`}
 	}
-
-	if !strings.EqualFold(err, "") {
-		return err, ""
-	}
-
-	return err, helper.GenerateChallengeResponse(challengeCode, config)
+	return nil
 }
@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package notificationtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	helper "github.com/ebay/event-notification-golang-sdk.git/lib/helper"
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//Post is used to send a notification to a handler as eBay does
+//Input
+//	handler - webhook handler
+//	body - notification body
+//	signature - X-EBAY-SIGNATURE header
+//Returns
+//	recorded response
+func Post(handler http.Handler, body []byte, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set(constants.ContentType, "application/json")
+	req.Header.Set(constants.XEbaySignature, signature)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+//Challenge is used to send an endpoint validation request to a handler as eBay does
+//Input
+//	handler - webhook handler
+//	challengeCode - challenge code
+//Returns
+//	recorded response
+func Challenge(handler http.Handler, challengeCode string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/?"+sdk.ChallengeCodeParam+"="+url.QueryEscape(challengeCode), nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+//AssertStatus is used to check the status of a response
+func AssertStatus(tb testing.TB, response *httptest.ResponseRecorder, status int) {
+	tb.Helper()
+	if response.Code != status {
+		tb.Errorf("expected status %d %s, got %d %s: %s", status, http.StatusText(status), response.Code, http.StatusText(response.Code), response.Body.String())
+	}
+}
+
+//AssertAccepted is used to check a notification was acknowledged with 204
+func AssertAccepted(tb testing.TB, response *httptest.ResponseRecorder) {
+	tb.Helper()
+	AssertStatus(tb, response, http.StatusNoContent)
+}
+
+//AssertRejected is used to check a notification was rejected with 412 for its signature
+func AssertRejected(tb testing.TB, response *httptest.ResponseRecorder) {
+	tb.Helper()
+	AssertStatus(tb, response, http.StatusPreconditionFailed)
+}
+
+//AssertRetried is used to check a notification failed with a status which makes eBay redeliver it
+func AssertRetried(tb testing.TB, response *httptest.ResponseRecorder) {
+	tb.Helper()
+	if response.Code < http.StatusInternalServerError && response.Code != http.StatusConflict && response.Code != http.StatusTooManyRequests {
+		tb.Errorf("expected a status making eBay redeliver, got %d %s: %s", response.Code, http.StatusText(response.Code), response.Body.String())
+	}
+}
+
+//AssertChallengeResponse is used to check the answer to an endpoint validation request
+//Input
+//	tb - test
+//	response - recorded response
+//	challengeCode - challenge code of the request
+//	config - verification token and endpoint registered with eBay
+func AssertChallengeResponse(tb testing.TB, response *httptest.ResponseRecorder, challengeCode string, config *pojo.Config) {
+	tb.Helper()
+	AssertStatus(tb, response, http.StatusOK)
+	if contentType := response.Header().Get(constants.ContentType); contentType != "application/json" {
+		tb.Errorf("expected application/json challenge response, got %q", contentType)
+	}
+	var body struct {
+		ChallengeResponse string `json:"challengeResponse"`
+	}
+	if err := json.Unmarshal(response.Body.Bytes(), &body); err != nil {
+		tb.Errorf("expected JSON challenge response, got %q: %v", response.Body.String(), err)
+		return
+	}
+	if expected := helper.GenerateChallengeResponse(challengeCode, config); body.ChallengeResponse != expected {
+		tb.Errorf("expected challengeResponse %s, got %s", expected, body.ChallengeResponse)
+	}
+}
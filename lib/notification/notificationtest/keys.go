@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package notificationtest
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	service "github.com/ebay/event-notification-golang-sdk.git/lib/service"
+)
+
+//KeyProvider serves the public keys of key pairs, with the algorithm they are published with.
+//Use it with notification.WithKeyProvider.
+type KeyProvider struct {
+	mu      sync.RWMutex
+	keys    map[string]*KeyPair
+	lookups uint64
+}
+
+//NewKeyProvider is used to create a key provider
+//Input
+//	keys - key pairs to serve
+//Returns
+//	key provider
+func NewKeyProvider(keys ...*KeyPair) *KeyProvider {
+	provider := &KeyProvider{keys: make(map[string]*KeyPair)}
+	for _, key := range keys {
+		provider.Add(key)
+	}
+	return provider
+}
+
+//Add is used to serve a key pair, e.g. to test key rotation
+func (p *KeyProvider) Add(key *KeyPair) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[key.Kid] = key
+}
+
+//Remove is used to stop serving a key id
+func (p *KeyProvider) Remove(kid string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.keys, kid)
+}
+
+//Lookups is used to get the number of key lookups, e.g. to test caching
+func (p *KeyProvider) Lookups() int {
+	return int(atomic.LoadUint64(&p.lookups))
+}
+
+//Key is used to get the public key of a key id
+func (p *KeyProvider) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	info, err := p.KeyInfo(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	return info.Key, nil
+}
+
+//KeyInfo is used to get the public key of a key id, published with ECDSA and the digest of the key pair
+func (p *KeyProvider) KeyInfo(ctx context.Context, kid string) (*service.KeyInfo, error) {
+	atomic.AddUint64(&p.lookups, 1)
+	p.mu.RLock()
+	key, ok := p.keys[kid]
+	p.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", service.ErrKeyNotFound, kid)
+	}
+	return &service.KeyInfo{Key: key.Public(), Algorithm: "ECDSA", Digest: key.Digest}, nil
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+This package signs notifications with generated keys, for unit tests of notification handlers
+*/
+package notificationtest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	helper "github.com/ebay/event-notification-golang-sdk.git/lib/helper"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+const (
+	//DefaultAlg is the alg of the signature headers, as sent by eBay
+	DefaultAlg = "ecdsa"
+	//DefaultDigest is the digest of the signatures, as sent by eBay
+	DefaultDigest = "SHA1"
+	//TimeFormat is the format of the dates in notifications
+	TimeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+//KeyPair is a generated ECDSA key pair and the key id it is published under
+type KeyPair struct {
+	Kid     string
+	Private *ecdsa.PrivateKey
+	//Digest is the digest published with the key and used to sign. Default: DefaultDigest
+	Digest string
+}
+
+//GenerateKey is used to generate a P-256 key pair, failing the test on error
+//Input
+//	tb - test
+//	kid - key id
+//Returns
+//	key pair
+func GenerateKey(tb testing.TB, kid string) *KeyPair {
+	tb.Helper()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		tb.Fatalf("notificationtest: failed to generate key: %v", err)
+	}
+	return &KeyPair{Kid: kid, Private: private, Digest: DefaultDigest}
+}
+
+//Public is used to get the public key
+func (k *KeyPair) Public() crypto.PublicKey {
+	return &k.Private.PublicKey
+}
+
+//signOptions are the fields of a signature header
+type signOptions struct {
+	kid    *string
+	alg    string
+	digest *string
+}
+
+//SignOption changes the signature header, e.g. to test rejected notifications
+type SignOption func(*signOptions)
+
+//WithKid sets the kid of the header, the body is still signed with the key pair
+func WithKid(kid string) SignOption {
+	return func(o *signOptions) {
+		o.kid = &kid
+	}
+}
+
+//WithAlg sets the alg of the header, the body is still signed with ECDSA. Default: DefaultAlg
+func WithAlg(alg string) SignOption {
+	return func(o *signOptions) {
+		o.alg = alg
+	}
+}
+
+//WithDigest sets the digest of the header, and signs with it. Spellings such as SHA-256 are accepted,
+//an empty digest is left out of the header. Default: the digest of the key pair
+func WithDigest(digest string) SignOption {
+	return func(o *signOptions) {
+		o.digest = &digest
+	}
+}
+
+//Sign is used to sign a raw body
+//Input
+//	body - body as it will be sent
+//	opts - changes to the header
+//Returns
+//	X-EBAY-SIGNATURE header
+//	error for an unsupported digest
+func (k *KeyPair) Sign(body []byte, opts ...SignOption) (string, error) {
+	options := signOptions{alg: DefaultAlg}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	kid, digest := k.Kid, k.Digest
+	if options.kid != nil {
+		kid = *options.kid
+	}
+	if options.digest != nil {
+		digest = *options.digest
+	}
+
+	hash, err := digestHash(digest)
+	if err != nil {
+		return "", err
+	}
+	hasher := hash.New()
+	hasher.Write(body)
+	signature, err := ecdsa.SignASN1(rand.Reader, k.Private, hasher.Sum(nil))
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(pojo.XeBaySignatureHeader{
+		Alg:       options.alg,
+		Kid:       kid,
+		Signature: base64.StdEncoding.EncodeToString(signature),
+		Digest:    digest,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(header), nil
+}
+
+//SignRaw is used to sign a raw body, failing the test on error
+//Input
+//	tb - test
+//	body - body as it will be sent
+//	opts - changes to the header
+//Returns
+//	X-EBAY-SIGNATURE header
+func (k *KeyPair) SignRaw(tb testing.TB, body []byte, opts ...SignOption) string {
+	tb.Helper()
+	header, err := k.Sign(body, opts...)
+	if err != nil {
+		tb.Fatalf("notificationtest: failed to sign: %v", err)
+	}
+	return header
+}
+
+//SignMessage is used to encode and sign a message, failing the test on error
+//Input
+//	tb - test
+//	message - notification
+//	opts - changes to the header
+//Returns
+//	body to send
+//	X-EBAY-SIGNATURE header
+func (k *KeyPair) SignMessage(tb testing.TB, message *pojo.Message, opts ...SignOption) ([]byte, string) {
+	tb.Helper()
+	body, err := json.Marshal(message)
+	if err != nil {
+		tb.Fatalf("notificationtest: failed to encode message: %v", err)
+	}
+	return body, k.SignRaw(tb, body, opts...)
+}
+
+//Returns the hash of a digest
+func digestHash(digest string) (crypto.Hash, error) {
+	switch helper.NormalizeAlgorithm(helper.AlgECDSA, digest).Digest {
+	case helper.DigestSHA1:
+		return crypto.SHA1, nil
+	case helper.DigestSHA256:
+		return crypto.SHA256, nil
+	case helper.DigestSHA384:
+		return crypto.SHA384, nil
+	case helper.DigestSHA512:
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("notificationtest: unsupported digest %q", digest)
+}
+
+//NewMessage is used to create a notification for a topic, with the sample data of the eBay documentation.
+//The event and publish dates are the current time, so the message passes replay protection.
+//Input
+//	topic - topic of the notification
+//	notificationID - notification id
+//Returns
+//	message
+func NewMessage(topic string, notificationID string) *pojo.Message {
+	now := time.Now().UTC().Format(TimeFormat)
+	return &pojo.Message{
+		Metadata: pojo.Metadata{Topic: topic, SchemaVersion: "1.0"},
+		Notification: pojo.Notification{
+			NotificationID:      notificationID,
+			EventDate:           now,
+			PublishDate:         now,
+			PublishAttemptCount: 1,
+			Data:                json.RawMessage(`{"username":"test_user","userId":"ma8vp1jySJC","eiasToken":"nY+sHZ2PrBmdj6wVnY+sEZ2PrA2dj6wJnY+gAZGEpwmdj6x9nY+seQ=="}`),
+		},
+	}
+}
@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	errs "github.com/ebay/event-notification-golang-sdk.git/lib/errs"
+	helper "github.com/ebay/event-notification-golang-sdk.git/lib/helper"
+	logging "github.com/ebay/event-notification-golang-sdk.git/lib/logging"
+	outbox "github.com/ebay/event-notification-golang-sdk.git/lib/outbox"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//WithOutbox persists verified notifications before acknowledging them, and removes them once processed.
+//Combined with WithAsync, notifications accepted before a crash are processed by Replay on the next start.
+func WithOutbox(store outbox.Store) Option {
+	return func(c *Client) {
+		c.outbox = store
+		c.inFlight = &inFlight{ids: make(map[string]struct{})}
+	}
+}
+
+//inFlight is the set of outbox entries being processed, which Replay leaves alone
+type inFlight struct {
+	mu  sync.Mutex
+	ids map[string]struct{}
+}
+
+//Claim an outbox entry
+//Returns
+//	false when the entry is already being processed
+func (f *inFlight) claim(id string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.ids[id]; ok {
+		return false
+	}
+	f.ids[id] = struct{}{}
+	return true
+}
+
+//Release a claimed outbox entry
+func (f *inFlight) release(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.ids, id)
+}
+
+//Persist a verified notification to the outbox
+//Input
+//	ctx - context of the store
+//	body - verified body
+//	signature - X-EBAY-SIGNATURE header
+//	receivedAt - receipt time of the notification
+//Returns
+//	outbox entry id, empty without outbox
+//	error matching ErrOutboxFailed
+func (c *Client) persist(ctx context.Context, body []byte, signature string, receivedAt time.Time) (string, error) {
+	if c.outbox == nil {
+		return "", nil
+	}
+	id, err := outbox.NewID()
+	if err != nil {
+		return "", errs.Wrap(ErrOutboxFailed, err)
+	}
+	entry := &outbox.Entry{ID: id, Body: body, Signature: signature, ReceivedAt: receivedAt}
+	// claimed before it can be seen by Replay
+	c.inFlight.claim(id)
+	if err := c.outbox.Append(ctx, entry); err != nil {
+		c.inFlight.release(id)
+		return "", errs.Wrap(ErrOutboxFailed, err)
+	}
+	return id, nil
+}
+
+//Remove a notification from the outbox once it no longer needs to be processed
+func (c *Client) ack(id string) {
+	if c.outbox == nil || id == "" {
+		return
+	}
+	// not bound to the request, which may already be done
+	if err := c.outbox.Ack(context.Background(), id); err != nil {
+		c.log().Error("Failed to remove notification from outbox", "entry", id, logging.KeyError, err)
+	}
+	c.inFlight.release(id)
+}
+
+//Leave a notification in the outbox for the next Replay
+func (c *Client) leave(id string) {
+	if c.outbox == nil || id == "" {
+		return
+	}
+	c.inFlight.release(id)
+}
+
+//Replay processes the notifications left in the outbox, e.g. by a crash, and should be called
+//on startup before serving requests. Signatures are verified again, entries failing verification
+//are discarded, entries whose public key cannot be fetched or failing in the processor are kept
+//for the next replay without blocking the other entries.
+//Entries still being processed by a request, a worker in async mode or another Replay are skipped,
+//so it may also be called periodically while serving to retry the failed ones.
+//Input
+//	ctx - context passed on to the key provider and processor
+//Returns
+//	number of notifications processed
+//	error matching ErrOutboxFailed, or the error of ctx, or an error matching ErrKeyFetchFailed
+//	joining the key failures of the entries left pending
+func (c *Client) Replay(ctx context.Context) (int, error) {
+	if c.outbox == nil {
+		return 0, nil
+	}
+	entries, err := c.outbox.Pending(ctx)
+	if err != nil {
+		return 0, errs.Wrap(ErrOutboxFailed, err)
+	}
+
+	replayed := 0
+	var keyFailures []error
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return replayed, err
+		}
+		if !c.inFlight.claim(entry.ID) {
+			continue
+		}
+		if err := helper.VerifyRaw(ctx, entry.Body, entry.Signature, c.keyProvider()); err != nil {
+			if errors.Is(err, ErrKeyFetchFailed) {
+				// e.g. a rotated key, the entry is not trusted until its key is back
+				c.log().Warn("Leaving notification in outbox", "entry", entry.ID, logging.KeyOutcome, logging.OutcomeFailed, logging.KeyError, err)
+				keyFailures = append(keyFailures, fmt.Errorf("entry %s: %w", entry.ID, err))
+				c.leave(entry.ID)
+				continue
+			}
+			c.log().Warn("Discarding notification from outbox", "entry", entry.ID, logging.KeyOutcome, logging.OutcomeRejected, logging.KeyError, err)
+			c.ack(entry.ID)
+			continue
+		}
+		var message pojo.Message
+		if err := json.Unmarshal(entry.Body, &message); err != nil {
+			c.log().Warn("Discarding notification from outbox", "entry", entry.ID, logging.KeyOutcome, logging.OutcomeRejected, logging.KeyError, err)
+			c.ack(entry.ID)
+			continue
+		}
+
+		if _, err := c.process(ctx, &message, entry.ReceivedAt); err != nil {
+			c.log().Error("Failed to replay notification", messageFields(&message, "entry", entry.ID, logging.KeyOutcome, logging.OutcomeFailed, logging.KeyError, err)...)
+			c.leave(entry.ID)
+			continue
+		}
+		c.ack(entry.ID)
+		replayed++
+	}
+	if len(keyFailures) > 0 {
+		return replayed, errs.Wrap(ErrKeyFetchFailed, fmt.Errorf("%d outbox entries left pending: %w", len(keyFailures), errors.Join(keyFailures...)))
+	}
+	return replayed, nil
+}
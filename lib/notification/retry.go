@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+package notification
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	deadletter "github.com/ebay/event-notification-golang-sdk.git/lib/deadletter"
+	logging "github.com/ebay/event-notification-golang-sdk.git/lib/logging"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+)
+
+//RetryPolicy configures how often a failing processor is retried before the notification
+//is dead lettered, or its failure reported to eBay. Permanent errors are not retried.
+type RetryPolicy struct {
+	//MaxAttempts is the number of times the processor is run, including the first one
+	MaxAttempts int
+	//InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	//MaxBackoff caps the delay between two attempts
+	MaxBackoff time.Duration
+	//Multiplier is applied to the delay after each retry
+	Multiplier float64
+	//Jitter is the fraction, between 0 and 1, by which a delay is randomly shortened
+	Jitter float64
+}
+
+//DefaultRetryPolicy makes 3 attempts, 200ms then 400ms apart, shortened by up to 20%
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+//jitter is the random source of the retry delays
+var jitter = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+//Backoff is used to get the delay before a retry
+//Input
+//	retry - number of the retry, starting at 1
+//Returns
+//	delay
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		jitter.Lock()
+		delay -= delay * math.Min(p.Jitter, 1) * jitter.Float64()
+		jitter.Unlock()
+	}
+	return time.Duration(delay)
+}
+
+//WithRetry sets the retry policy of failing processors. Default: no retry
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+//Run the processor, retrying failures according to the retry policy
+//Input
+//	ctx - context passed on to the processor, retries stop when it is done
+//	obj - processor
+//	message - verified message
+//Returns
+//	history of the failed attempts
+//	error of the last attempt
+func (c *Client) execute(ctx context.Context, obj processor.ContextProcessor, message *pojo.Message) ([]deadletter.Attempt, error) {
+	// processors log with the logger of the client
+	ctx = logging.NewContext(ctx, c.log())
+	var attempts []deadletter.Attempt
+	for number := 1; ; number++ {
+		startedAt := c.now()
+		err := processor.SafeProcess(ctx, obj, message)
+		if err == nil {
+			return attempts, nil
+		}
+		var panicErr *processor.PanicError
+		if errors.As(err, &panicErr) {
+			c.log().Error("Processor panicked", messageFields(message, logging.KeyAttempt, number, logging.KeyError, err, "stack", string(panicErr.Stack))...)
+		} else {
+			c.log().Warn("Processor failed", messageFields(message, logging.KeyAttempt, number, logging.KeyError, err)...)
+		}
+		attempts = append(attempts, deadletter.Attempt{Number: number, StartedAt: startedAt, Error: err.Error()})
+		if processor.IsPermanent(err) || number >= c.retry.MaxAttempts {
+			return attempts, err
+		}
+
+		timer := time.NewTimer(c.retry.Backoff(number))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempts, err
+		case <-timer.C:
+		}
+	}
+}
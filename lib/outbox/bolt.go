@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+//DefaultBucket is the bucket used by the bbolt store
+var DefaultBucket = []byte("ebay_notification_outbox")
+
+//BoltStore keeps entries in a bbolt database
+type BoltStore struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+//NewBoltStore is used to create a store on a bbolt database
+//Input
+//	db - database, opened by the caller
+//Returns
+//	bolt store
+//	error when the bucket cannot be created
+func NewBoltStore(db *bolt.DB) (*BoltStore, error) {
+	s := &BoltStore{db: db, bucket: DefaultBucket}
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(s.bucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+//Append persists an entry
+func (s *BoltStore) Append(ctx context.Context, entry *Entry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Put([]byte(entry.ID), value)
+	})
+}
+
+//Ack removes a processed entry
+func (s *BoltStore) Ack(ctx context.Context, id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Delete([]byte(id))
+	})
+}
+
+//Pending returns the entries not acknowledged, oldest first
+func (s *BoltStore) Pending(ctx context.Context) ([]*Entry, error) {
+	var entries []*Entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).ForEach(func(key, value []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return err
+			}
+			entries = append(entries, &entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortEntries(entries)
+	return entries, nil
+}
@@ -0,0 +1,225 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+//compactThreshold is the number of acknowledged entries after which the journal is rewritten
+const compactThreshold = 1000
+
+//journal operations
+const (
+	opAppend = "append"
+	opAck    = "ack"
+)
+
+//record is a line of the journal
+type record struct {
+	Op    string `json:"op"`
+	Entry *Entry `json:"entry,omitempty"`
+	ID    string `json:"id,omitempty"`
+}
+
+//FileStore is an append-only journal file, synced on every write and compacted as entries are acknowledged
+type FileStore struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	pending map[string]*Entry
+	acked   int
+}
+
+//OpenFileStore is used to open a journal, replaying it to find the pending entries
+//Input
+//	path - journal file, created when missing
+//Returns
+//	file store
+//	error when the journal cannot be read or opened
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, pending: make(map[string]*Entry)}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	s.file = file
+	return s, nil
+}
+
+//Read the journal
+func (s *FileStore) load() error {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// a line torn by a crash while it was written
+			continue
+		}
+		switch r.Op {
+		case opAppend:
+			if r.Entry != nil {
+				s.pending[r.Entry.ID] = r.Entry
+			}
+		case opAck:
+			delete(s.pending, r.ID)
+			s.acked++
+		}
+	}
+	return scanner.Err()
+}
+
+//Append persists an entry
+func (s *FileStore) Append(ctx context.Context, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.write(record{Op: opAppend, Entry: entry}); err != nil {
+		return err
+	}
+	s.pending[entry.ID] = entry
+	return nil
+}
+
+//Ack removes a processed entry
+func (s *FileStore) Ack(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.pending[id]; !ok {
+		return nil
+	}
+	if err := s.write(record{Op: opAck, ID: id}); err != nil {
+		return err
+	}
+	delete(s.pending, id)
+	s.acked++
+	if s.acked >= compactThreshold && s.acked > len(s.pending) {
+		return s.compact()
+	}
+	return nil
+}
+
+//Pending returns the entries not acknowledged, oldest first
+func (s *FileStore) Pending(ctx context.Context) ([]*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]*Entry, 0, len(s.pending))
+	for _, entry := range s.pending {
+		entries = append(entries, entry)
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+//Close is used to close the journal
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+//Write a record and sync it to disk
+func (s *FileStore) write(r record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+//Rewrite the journal with the pending entries only
+func (s *FileStore) compact() error {
+	temp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	writer := bufio.NewWriter(temp)
+	for _, entry := range s.pending {
+		line, err := json.Marshal(record{Op: opAppend, Entry: entry})
+		if err != nil {
+			temp.Close()
+			return err
+		}
+		writer.Write(append(line, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), s.path); err != nil {
+		return err
+	}
+	// the rename is only durable once the directory is synced
+	if err := syncDir(filepath.Dir(s.path)); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	s.file.Close()
+	s.file = file
+	s.acked = 0
+	return nil
+}
+
+//Sync a directory to disk so a rename in it survives a crash, skipped on Windows which cannot sync directories
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = file.Sync()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+This package persists verified notifications before they are acknowledged,
+so they survive a crash and are processed at least once
+*/
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"time"
+)
+
+//Entry is a verified notification as received from eBay
+type Entry struct {
+	ID         string    `json:"id"`
+	Body       []byte    `json:"body"`
+	Signature  string    `json:"signature"`
+	ReceivedAt time.Time `json:"receivedAt"`
+}
+
+//Store persists entries until they are processed.
+//Implementations must be safe for concurrent use and durable once Append returns.
+type Store interface {
+	//Append persists an entry
+	Append(ctx context.Context, entry *Entry) error
+	//Ack removes a processed entry
+	Ack(ctx context.Context, id string) error
+	//Pending returns the entries not acknowledged, oldest first
+	Pending(ctx context.Context) ([]*Entry, error)
+}
+
+//NewID is used to generate a random entry id
+func NewID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+//Sort entries oldest first
+func sortEntries(entries []*Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ReceivedAt.Before(entries[j].ReceivedAt)
+	})
+}
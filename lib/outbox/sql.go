@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+//DefaultTable is the table used by the SQL store
+const DefaultTable = "ebay_notification_outbox"
+
+//SQLOption configures the SQL store
+type SQLOption func(*SQLStore)
+
+//WithTable sets the table of the SQL store, which must be a trusted identifier. Default: DefaultTable
+func WithTable(table string) SQLOption {
+	return func(s *SQLStore) {
+		s.table = table
+	}
+}
+
+//WithPlaceholder sets the query parameter style, e.g. dedup.DollarPlaceholder for PostgreSQL. Default: ?
+func WithPlaceholder(placeholder func(n int) string) SQLOption {
+	return func(s *SQLStore) {
+		s.placeholder = placeholder
+	}
+}
+
+//SQLStore keeps entries in a database/sql table, e.g. SQLite
+type SQLStore struct {
+	db          *sql.DB
+	table       string
+	placeholder func(n int) string
+}
+
+//NewSQLStore is used to create a store on a database
+//Input
+//	db - database, with the table created by CreateTable
+//	opts - options, WithTable and WithPlaceholder
+//Returns
+//	sql store
+func NewSQLStore(db *sql.DB, opts ...SQLOption) *SQLStore {
+	s := &SQLStore{db: db, table: DefaultTable, placeholder: func(int) string { return "?" }}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+//CreateTable is used to create the table of the store when it does not exist
+func (s *SQLStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s ("+
+		"id VARCHAR(64) NOT NULL PRIMARY KEY, "+
+		"body TEXT NOT NULL, "+
+		"signature TEXT NOT NULL, "+
+		"received_at BIGINT NOT NULL)", s.table))
+	return err
+}
+
+//Append persists an entry
+func (s *SQLStore) Append(ctx context.Context, entry *Entry) error {
+	p := s.placeholder
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (id, body, signature, received_at) VALUES (%s, %s, %s, %s)",
+		s.table, p(1), p(2), p(3), p(4)), entry.ID, string(entry.Body), entry.Signature, entry.ReceivedAt.UnixNano())
+	return err
+}
+
+//Ack removes a processed entry
+func (s *SQLStore) Ack(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = %s", s.table, s.placeholder(1)), id)
+	return err
+}
+
+//Pending returns the entries not acknowledged, oldest first
+func (s *SQLStore) Pending(ctx context.Context) ([]*Entry, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT id, body, signature, received_at FROM %s ORDER BY received_at", s.table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*Entry
+	for rows.Next() {
+		var entry Entry
+		var body string
+		var receivedAt int64
+		if err := rows.Scan(&entry.ID, &body, &entry.Signature, &receivedAt); err != nil {
+			return nil, err
+		}
+		entry.Body = []byte(body)
+		entry.ReceivedAt = time.Unix(0, receivedAt)
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
@@ -16,6 +16,8 @@ This package contains all required pojo
 */
 package pojo
 
+import "encoding/json"
+
 //Config is configuration file object
 type Config struct {
 	Sandbox           Environment `json:"SANDBOX"`
@@ -66,14 +68,15 @@ type Metadata struct {
 
 //Notification is notification object
 type Notification struct {
-	NotificationID      string      `json:"notificationId"`
-	EventDate           string      `json:"eventDate"`
-	PublishDate         string      `json:"publishDate"`
-	PublishAttemptCount int         `json:"publishAttemptCount"`
-	PayloadData         PayloadData `json:"data"`
+	NotificationID      string `json:"notificationId"`
+	EventDate           string `json:"eventDate"`
+	PublishDate         string `json:"publishDate"`
+	PublishAttemptCount int    `json:"publishAttemptCount"`
+	//Data is the topic specific payload as received, see processor.Typed to decode it
+	Data json.RawMessage `json:"data"`
 }
 
-//PayloadData is user payload
+//PayloadData is the payload of MARKETPLACE_ACCOUNT_DELETION notifications
 type PayloadData struct {
 	Username  string `json:"username"`
 	UserID    string `json:"userId"`
@@ -0,0 +1,185 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+This package probes a deployed webhook endpoint with the validation challenge eBay sends
+*/
+package probe
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	helper "github.com/ebay/event-notification-golang-sdk.git/lib/helper"
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//challengeResponse is the body eBay expects in answer to the challenge
+type challengeResponse struct {
+	ChallengeResponse string `json:"challengeResponse"`
+}
+
+//Result is the outcome of an endpoint validation probe
+type Result struct {
+	//URL is the requested URL, including the challenge code
+	URL           string
+	ChallengeCode string
+	StatusCode    int
+	ContentType   string
+	//Expected is the challenge response eBay expects
+	Expected string
+	//Received is the challenge response of the endpoint
+	Received string
+	//Problems are the mismatches found, empty when the endpoint answers as eBay expects
+	Problems []string
+}
+
+//OK reports whether the endpoint answers the challenge as eBay expects
+func (p *Result) OK() bool {
+	return len(p.Problems) == 0
+}
+
+//Endpoint is used to send a random challenge code to a deployed endpoint, as eBay does when
+//the endpoint is saved in the developer portal, and check its response. When the response does not
+//match, responses computed from variants of the endpoint are compared to point at the likely cause,
+//such as a trailing slash or http instead of https.
+//Input
+//	ctx - context of the request
+//	httpClient - client sending the request, http.DefaultClient when nil
+//	config - verification token and endpoint registered with eBay
+//	target - URL to call, the config endpoint when empty
+//Returns
+//	probe result
+//	error matching notification.ErrInvalidConfig, or when the request fails
+func Endpoint(ctx context.Context, httpClient *http.Client, config *pojo.Config, target string) (*Result, error) {
+	code := make([]byte, 16)
+	if _, err := rand.Read(code); err != nil {
+		return nil, err
+	}
+	probe := &Result{ChallengeCode: hex.EncodeToString(code)}
+	expected, err := sdk.ChallengeResponse(probe.ChallengeCode, config)
+	if err != nil {
+		return nil, err
+	}
+	probe.Expected = expected
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if target == "" {
+		target = config.Endpoint
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	query.Set(sdk.ChallengeCodeParam, probe.ChallengeCode)
+	u.RawQuery = query.Encode()
+	probe.URL = u.String()
+
+	if endpoint, err := url.Parse(config.Endpoint); err != nil || endpoint.Scheme != "https" {
+		probe.Problems = append(probe.Problems, fmt.Sprintf("endpoint %q is not an https URL, eBay only calls https endpoints", config.Endpoint))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probe.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	probe.StatusCode = resp.StatusCode
+	probe.ContentType = resp.Header.Get(constants.ContentType)
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		probe.Problems = append(probe.Problems, fmt.Sprintf("status %d, expected 200", resp.StatusCode))
+	}
+	if mediaType, _, err := mime.ParseMediaType(probe.ContentType); err != nil || mediaType != "application/json" {
+		probe.Problems = append(probe.Problems, fmt.Sprintf("content type %q, expected application/json", probe.ContentType))
+	}
+	var response challengeResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		probe.Problems = append(probe.Problems, fmt.Sprintf(`body is not a JSON object {"challengeResponse": "..."}: %v`, err))
+		return probe, nil
+	}
+	probe.Received = response.ChallengeResponse
+	switch {
+	case probe.Received == "":
+		probe.Problems = append(probe.Problems, `body has no "challengeResponse" field`)
+	case probe.Received != probe.Expected:
+		probe.Problems = append(probe.Problems, probe.mismatch(config, target))
+	}
+	return probe, nil
+}
+
+//Explain a challenge response mismatch by finding the endpoint the server used
+func (p *Result) mismatch(config *pojo.Config, target string) string {
+	endpoint := config.Endpoint
+	slash := toggleTrailingSlash(endpoint)
+	scheme := toggleScheme(endpoint)
+	variants := []struct {
+		endpoint string
+		cause    string
+	}{
+		{slash, "trailing slash"},
+		{scheme, "http vs https"},
+		{toggleTrailingSlash(scheme), "http vs https and trailing slash"},
+		{p.URL, "the challenge code query string is included in the endpoint"},
+		{target, "the endpoint differs from the probed URL"},
+	}
+	for _, variant := range variants {
+		if variant.endpoint == endpoint {
+			continue
+		}
+		variantConfig := &pojo.Config{Endpoint: variant.endpoint, VerificationToken: config.VerificationToken}
+		if helper.GenerateChallengeResponse(p.ChallengeCode, variantConfig) == p.Received {
+			return fmt.Sprintf("challengeResponse was computed with endpoint %q instead of %q: %s", variant.endpoint, endpoint, variant.cause)
+		}
+	}
+	return "challengeResponse does not match: check the verification token and endpoint configured on the server"
+}
+
+//Returns the URL with a trailing slash added or removed
+func toggleTrailingSlash(endpoint string) string {
+	if strings.HasSuffix(endpoint, "/") {
+		return strings.TrimSuffix(endpoint, "/")
+	}
+	return endpoint + "/"
+}
+
+//Returns the URL with http and https swapped
+func toggleScheme(endpoint string) string {
+	if strings.HasPrefix(endpoint, "https://") {
+		return "http://" + strings.TrimPrefix(endpoint, "https://")
+	} else if strings.HasPrefix(endpoint, "http://") {
+		return "https://" + strings.TrimPrefix(endpoint, "http://")
+	}
+	return endpoint
+}
@@ -17,21 +17,38 @@ This package include message processor to process message based on their topics
 package processor
 
 import (
-	"fmt"
+	"context"
 
+	logging "github.com/ebay/event-notification-golang-sdk.git/lib/logging"
 	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
 )
 
 //AccountDeletionMessageProcessor is to process account deletion
 type AccountDeletionMessageProcessor struct {
+	//Logger receives the account deletions, with the PII fields hashed unless it is wrapped with
+	//logging.Redacting. Default: the logger of the client processing the notification, or logging.Default
+	Logger logging.Logger
 }
 
 //Implemenation for processing account deletion messages
 //Input
 //	message to be processed
 func (a AccountDeletionMessageProcessor) Process(message *pojo.Message) {
-	fmt.Println("Accoutn deletion processing")
-	data := message.Notification.PayloadData
-	fmt.Println(fmt.Sprintf(`\n==========================\nUser ID: %s`, data.UserID))
-	fmt.Println(fmt.Sprintf("Username: %s\n==========================\n", data.Username))
+	a.processContext(context.Background(), message)
+}
+
+//Log an account deletion with the logger of the processor, or of the context
+func (a AccountDeletionMessageProcessor) processContext(ctx context.Context, message *pojo.Message) {
+	logger := logging.FromContext(ctx)
+	if a.Logger != nil {
+		logger = logging.Redacting(a.Logger, logging.Hash)
+	}
+	fields := []any{logging.KeyTopic, message.Metadata.Topic, logging.KeyNotificationID, message.Notification.NotificationID}
+	var data pojo.PayloadData
+	if err := DecodeData(message, &data); err != nil {
+		logger.Warn("Failed to decode account deletion", append(fields, logging.KeyError, err)...)
+		return
+	}
+	// the PII fields of the payload are hashed by the logger
+	logger.Info("Processing account deletion", append(fields, "data", logging.Payload(data))...)
 }
@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package processor
+
+import (
+	"errors"
+	"fmt"
+)
+
+//PermanentError marks a processing failure which will not succeed on redelivery
+type PermanentError struct {
+	Err error
+}
+
+func (p *PermanentError) Error() string {
+	return "permanent processing failure: " + p.Err.Error()
+}
+
+func (p *PermanentError) Unwrap() error {
+	return p.Err
+}
+
+//Permanent is used to mark an error as permanent, so the notification is
+//acknowledged and dropped instead of being redelivered by eBay
+//Input
+//	err - processing error
+//Returns
+//	permanent error wrapping err, nil if err is nil
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+//IsPermanent is used to check whether a processing error was marked with Permanent
+//Input
+//	err - processing error
+//Returns
+//	true if the error should not be retried
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+//PanicError is returned by SafeProcess when the processor panics
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("processor panic: %v", p.Value)
+}
@@ -17,28 +17,124 @@ This package include message processor to process message based on their topics
 package processor
 
 import (
-	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+
+	errs "github.com/ebay/event-notification-golang-sdk.git/lib/errs"
 	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
 )
 
+//ErrNilProcessor is matched by the errors of registrations with a nil processor
+var ErrNilProcessor = errors.New("nil processor")
+
 //Processor is generice processor for message processing by topics
 type Processor interface {
 	Process(*pojo.Message)
 }
 
-var obj Processor
+//ContextProcessor is the error returning processor for message processing by topics.
+//A returned error is reported to eBay with a 500 HTTP status code so the notification
+//is redelivered, unless it is marked with Permanent.
+type ContextProcessor interface {
+	Process(ctx context.Context, message *pojo.Message) error
+}
+
+//ProcessorFunc is an adapter to use an ordinary function as a ContextProcessor
+type ProcessorFunc func(ctx context.Context, message *pojo.Message) error
+
+//Process calls f(ctx, message)
+func (f ProcessorFunc) Process(ctx context.Context, message *pojo.Message) error {
+	return f(ctx, message)
+}
+
+//legacyProcessor adapts a Processor to the ContextProcessor interface
+type legacyProcessor struct {
+	processor Processor
+}
+
+func (l legacyProcessor) Process(ctx context.Context, message *pojo.Message) error {
+	if p, ok := l.processor.(contextAware); ok {
+		p.processContext(ctx, message)
+		return nil
+	}
+	l.processor.Process(message)
+	return nil
+}
+
+//contextAware is implemented by the processors of this package which use the context when
+//registered as a Processor, e.g. for the logger of the client
+type contextAware interface {
+	processContext(ctx context.Context, message *pojo.Message)
+}
+
+//contextProcessor adapts a ContextProcessor to the Processor interface
+type contextProcessor struct {
+	processor ContextProcessor
+}
+
+func (c contextProcessor) Process(message *pojo.Message) {
+	c.processor.Process(context.Background(), message)
+}
+
+//Adapt is used to convert a Processor into a ContextProcessor which never fails
+//Input
+//	p - processor to be adapted
+//Returns
+//	context aware processor, nil if p is nil
+func Adapt(p Processor) ContextProcessor {
+	if isNil(p) {
+		return nil
+	}
+	if c, ok := p.(contextProcessor); ok {
+		return c.processor
+	}
+	return legacyProcessor{p}
+}
 
-//GetProcessor is used to get processor for specified topic
+//GetProcessor is used to get processor for specified topic from the DefaultRegistry
 //Input
 //	topic to be processed
 //Returns
 //	processor for the topic
-func GetProcessor(topic string) Processor {
-	switch topic {
-	case constants.TopicsMarketplaceAccountDeletion:
-		obj := AccountDeletionMessageProcessor{}
-		return obj
-	default:
-		panic("Message processor not registered for " + topic)
+//	error wrapping errs.ErrUnknownTopic when no processor is registered
+func GetProcessor(topic string) (Processor, error) {
+	obj, ok := DefaultRegistry.Lookup(topic)
+	if !ok {
+		return nil, errs.Wrap(errs.ErrUnknownTopic, fmt.Errorf("topic %s", topic))
+	}
+	if l, isLegacy := obj.(legacyProcessor); isLegacy {
+		return l.processor, nil
+	}
+	return contextProcessor{obj}, nil
+}
+
+//SafeProcess is used to run a processor, converting a panic into an error
+//Input
+//	ctx - context passed on to the processor
+//	p - processor to run
+//	message - message to be processed
+//Returns
+//	error returned by the processor, or a *PanicError
+func SafeProcess(ctx context.Context, p ContextProcessor, message *pojo.Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return p.Process(ctx, message)
+}
+
+//Reports whether v is nil, or holds a nil pointer, map, slice, channel or function
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch value := reflect.ValueOf(v); value.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		return value.IsNil()
 	}
+	return false
 }
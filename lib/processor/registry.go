@@ -0,0 +1,382 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package processor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//Wildcard is the suffix used to register a processor for every topic sharing a prefix
+const Wildcard = "*"
+
+//Registry maps notification topics to the processors handling them.
+//A topic is resolved by exact match first, then by the longest matching
+//wildcard pattern (e.g. "MARKETPLACE_*"), and finally by the default processor.
+//For each of them, the processor registered for the most specific schema version range
+//containing the version of the notification wins over the one registered for every version.
+//A Registry is safe for concurrent use.
+type Registry struct {
+	mu         sync.RWMutex
+	processors map[string]ContextProcessor
+	prefixes   map[string]ContextProcessor
+	versioned  map[string][]versioned
+	upcasters  map[string][]upcaster
+	fallback   ContextProcessor
+}
+
+//versioned is a processor registered for a schema version range
+type versioned struct {
+	versions  VersionRange
+	processor ContextProcessor
+}
+
+//DefaultRegistry is the registry consulted by notification.ValidateAndProcess
+var DefaultRegistry = newDefaultRegistry()
+
+//NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		processors: make(map[string]ContextProcessor),
+		prefixes:   make(map[string]ContextProcessor),
+		versioned:  make(map[string][]versioned),
+		upcasters:  make(map[string][]upcaster),
+	}
+}
+
+func newDefaultRegistry() *Registry {
+	registry := NewRegistry()
+	registry.Register(constants.TopicsMarketplaceAccountDeletion, AccountDeletionMessageProcessor{})
+	return registry
+}
+
+//Register is used to register a processor for a topic, replacing any existing one
+//Input
+//	topic - topic name, or a prefix followed by "*" to match every topic starting with it
+//	p - processor for the topic
+//Returns
+//	error matching ErrNilProcessor when p is nil
+func (r *Registry) Register(topic string, p Processor) error {
+	return r.RegisterContext(topic, Adapt(p))
+}
+
+//RegisterContext is used to register a context aware processor for a topic, replacing any existing one
+//Input
+//	topic - topic name, or a prefix followed by "*" to match every topic starting with it
+//	p - processor for the topic
+//Returns
+//	error matching ErrNilProcessor when p is nil
+func (r *Registry) RegisterContext(topic string, p ContextProcessor) error {
+	if isNil(p) {
+		return fmt.Errorf("%w for %s", ErrNilProcessor, topic)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if strings.HasSuffix(topic, Wildcard) {
+		r.prefixes[strings.TrimSuffix(topic, Wildcard)] = p
+		return nil
+	}
+	r.processors[topic] = p
+	return nil
+}
+
+//RegisterVersion is used to register a context aware processor for the schema versions in a range
+//of a topic, replacing any existing one registered for the same range, e.g. "1.*" replaces "1.x"
+//Input
+//	topic - topic name, or a prefix followed by "*" to match every topic starting with it
+//	versions - schema version range, e.g. "1.x", see ParseVersionRange
+//	p - processor for the topic
+//Returns
+//	error matching ErrNilProcessor when p is nil, or when the version range is malformed or overlaps
+//	a range registered for the topic
+func (r *Registry) RegisterVersion(topic string, versions string, p ContextProcessor) error {
+	if isNil(p) {
+		return fmt.Errorf("%w for %s %s", ErrNilProcessor, topic, versions)
+	}
+	versionRange, err := ParseVersionRange(versions)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.versioned[topic]
+	for i, entry := range entries {
+		if entry.versions.Equal(versionRange) {
+			entries[i] = versioned{versions: versionRange, processor: p}
+			return nil
+		}
+		if entry.versions.Overlaps(versionRange) {
+			return fmt.Errorf("version range %q overlaps %q registered for %s", versions, entry.versions, topic)
+		}
+	}
+	r.versioned[topic] = append(entries, versioned{versions: versionRange, processor: p})
+	return nil
+}
+
+//UnregisterVersion is used to remove the processor registered for a schema version range of a topic
+//Input
+//	topic - topic name or wildcard pattern used in RegisterVersion
+//	versions - version range used in RegisterVersion, or an equal one
+func (r *Registry) UnregisterVersion(topic string, versions string) {
+	versionRange, err := ParseVersionRange(versions)
+	if err != nil {
+		// a malformed range cannot have been registered
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.versioned[topic]
+	for i, entry := range entries {
+		if entry.versions.Equal(versionRange) {
+			entries = append(entries[:i:i], entries[i+1:]...)
+			break
+		}
+	}
+	if len(entries) == 0 {
+		delete(r.versioned, topic)
+		return
+	}
+	r.versioned[topic] = entries
+}
+
+//RegisterUpcaster is used to register an upcaster converting the data of older schema versions of
+//a topic. Notifications with a version in the range are converted and routed to the processor of
+//the target version, upcasters are chained until none matches the converted version.
+//Input
+//	topic - topic name
+//	from - schema version range converted by the upcaster, e.g. "1.x"
+//	to - schema version of the converted data, e.g. "2.0"
+//	u - upcaster
+//Returns
+//	error when u is nil, the version range or target version is malformed, or the range overlaps
+//	the range of an upcaster registered for the topic
+func (r *Registry) RegisterUpcaster(topic string, from string, to string, u Upcaster) error {
+	versionRange, err := ParseVersionRange(from)
+	if err != nil {
+		return err
+	}
+	if _, ok := parseVersion(to); !ok {
+		return fmt.Errorf("invalid target schema version %q", to)
+	}
+	if isNil(u) {
+		return fmt.Errorf("nil upcaster for %s %s", topic, from)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.upcasters[topic]
+	for i, entry := range entries {
+		if entry.from.Equal(versionRange) {
+			entries[i] = upcaster{from: versionRange, to: to, upcaster: u}
+			return nil
+		}
+		if entry.from.Overlaps(versionRange) {
+			return fmt.Errorf("version range %q overlaps %q registered for %s", from, entry.from, topic)
+		}
+	}
+	r.upcasters[topic] = append(entries, upcaster{from: versionRange, to: to, upcaster: u})
+	return nil
+}
+
+//Unregister is used to remove the processors registered for a topic or wildcard pattern,
+//including the ones registered for schema version ranges
+//Input
+//	topic - topic name or wildcard pattern used in Register
+func (r *Registry) Unregister(topic string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.versioned, topic)
+	if strings.HasSuffix(topic, Wildcard) {
+		delete(r.prefixes, strings.TrimSuffix(topic, Wildcard))
+		return
+	}
+	delete(r.processors, topic)
+}
+
+//SetDefault is used to set the processor for topics with no registered processor
+//Input
+//	p - fallback processor, nil to remove it
+func (r *Registry) SetDefault(p Processor) {
+	r.SetDefaultContext(Adapt(p))
+}
+
+//SetDefaultContext is used to set the context aware processor for topics with no registered processor
+//Input
+//	p - fallback processor, nil to remove it
+func (r *Registry) SetDefaultContext(p ContextProcessor) {
+	if isNil(p) {
+		p = nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = p
+}
+
+//Lookup is used to find the processor for a topic, ignoring processors registered for version ranges
+//other than "x"
+//Input
+//	topic - topic to be processed
+//Returns
+//	processor for the topic
+//	false if no processor matches the topic
+func (r *Registry) Lookup(topic string) (ContextProcessor, bool) {
+	return r.LookupVersion(topic, "")
+}
+
+//LookupVersion is used to find the processor for a schema version of a topic
+//Input
+//	topic - topic to be processed
+//	version - schema version of the notification
+//Returns
+//	processor for the topic and version
+//	false if no processor matches the topic and version
+func (r *Registry) LookupVersion(topic string, version string) (ContextProcessor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lookup(topic, version)
+}
+
+//Resolve is used to find the processor for a notification. When upcasters are registered for
+//its schema version, the returned processor runs them on a copy of the message and fails with
+//a permanent *UpcastError when one of them fails.
+//Input
+//	message - notification to be processed
+//Returns
+//	processor for the notification
+//	false if no processor matches the topic and upcast version
+func (r *Registry) Resolve(message *pojo.Message) (ContextProcessor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	topic, version := message.Metadata.Topic, message.Metadata.SchemaVersion
+	var steps []upcaster
+	visited := map[string]bool{version: true}
+	for {
+		step, ok := r.upcaster(topic, version)
+		if !ok || visited[step.to] {
+			break
+		}
+		steps = append(steps, step)
+		version = step.to
+		visited[version] = true
+	}
+	p, ok := r.lookup(topic, version)
+	if !ok || len(steps) == 0 {
+		return p, ok
+	}
+	return upcastProcessor{steps: steps, processor: p}, true
+}
+
+//Find the processor for a schema version of a topic, the read lock must be held
+func (r *Registry) lookup(topic string, version string) (ContextProcessor, bool) {
+	if p, ok := r.match(topic, version, r.processors[topic]); ok {
+		return p, true
+	}
+	var prefixes []string
+	for prefix := range r.prefixes {
+		if strings.HasPrefix(topic, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	for pattern := range r.versioned {
+		prefix := strings.TrimSuffix(pattern, Wildcard)
+		if prefix != pattern && strings.HasPrefix(topic, prefix) {
+			if _, ok := r.prefixes[prefix]; !ok {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	for _, prefix := range prefixes {
+		if p, ok := r.match(prefix+Wildcard, version, r.prefixes[prefix]); ok {
+			return p, true
+		}
+	}
+	if r.fallback != nil {
+		return r.fallback, true
+	}
+	return nil, false
+}
+
+//Find the processor of the most specific version range of a pattern containing the version,
+//or the processor registered for every version
+func (r *Registry) match(pattern string, version string, unversioned ContextProcessor) (ContextProcessor, bool) {
+	var match ContextProcessor
+	best := -1
+	for _, entry := range r.versioned[pattern] {
+		if entry.versions.specificity() > best && entry.versions.Contains(version) {
+			match = entry.processor
+			best = entry.versions.specificity()
+		}
+	}
+	if match != nil {
+		return match, true
+	}
+	return unversioned, unversioned != nil
+}
+
+//Find the upcaster of the most specific version range containing the version
+func (r *Registry) upcaster(topic string, version string) (upcaster, bool) {
+	var match upcaster
+	best := -1
+	for _, entry := range r.upcasters[topic] {
+		if entry.from.specificity() > best && entry.from.Contains(version) {
+			match = entry
+			best = entry.from.specificity()
+		}
+	}
+	return match, best >= 0
+}
+
+//Register is used to register a processor for a topic in the DefaultRegistry
+func Register(topic string, p Processor) error {
+	return DefaultRegistry.Register(topic, p)
+}
+
+//RegisterContext is used to register a context aware processor for a topic in the DefaultRegistry
+func RegisterContext(topic string, p ContextProcessor) error {
+	return DefaultRegistry.RegisterContext(topic, p)
+}
+
+//RegisterVersion is used to register a context aware processor for a schema version range of a topic
+//in the DefaultRegistry
+func RegisterVersion(topic string, versions string, p ContextProcessor) error {
+	return DefaultRegistry.RegisterVersion(topic, versions, p)
+}
+
+//RegisterUpcaster is used to register an upcaster for a topic in the DefaultRegistry
+func RegisterUpcaster(topic string, from string, to string, u Upcaster) error {
+	return DefaultRegistry.RegisterUpcaster(topic, from, to, u)
+}
+
+//Unregister is used to remove a processor from the DefaultRegistry
+func Unregister(topic string) {
+	DefaultRegistry.Unregister(topic)
+}
+
+//SetDefault is used to set the fallback processor of the DefaultRegistry
+func SetDefault(p Processor) {
+	DefaultRegistry.SetDefault(p)
+}
+
+//SetDefaultContext is used to set the context aware fallback processor of the DefaultRegistry
+func SetDefaultContext(p ContextProcessor) {
+	DefaultRegistry.SetDefaultContext(p)
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//ErrPayloadMismatch is matched by the errors of notifications whose data does not match the payload type
+var ErrPayloadMismatch = errors.New("notification data does not match payload type")
+
+//PayloadError is returned when the data of a notification cannot be decoded into the payload type
+type PayloadError struct {
+	Topic         string
+	SchemaVersion string
+	//Type is the name of the payload type
+	Type string
+	Err  error
+}
+
+func (p *PayloadError) Error() string {
+	return fmt.Sprintf("data of %s notification with schema version %s does not match %s: %v", p.Topic, p.SchemaVersion, p.Type, p.Err)
+}
+
+func (p *PayloadError) Unwrap() error {
+	return p.Err
+}
+
+//Is reports whether target is ErrPayloadMismatch
+func (p *PayloadError) Is(target error) bool {
+	return target == ErrPayloadMismatch
+}
+
+//DecodeData is used to decode the data of a notification
+//Input
+//	message - notification
+//	v - pointer to the payload
+//Returns
+//	*PayloadError when the data is missing or does not match the payload
+func DecodeData(message *pojo.Message, v interface{}) error {
+	data := bytes.TrimSpace(message.Notification.Data)
+	var err error
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		err = errors.New("missing data")
+	} else {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		return &PayloadError{
+			Topic:         message.Metadata.Topic,
+			SchemaVersion: message.Metadata.SchemaVersion,
+			Type:          reflect.TypeOf(v).Elem().String(),
+			Err:           err,
+		}
+	}
+	return nil
+}
+
+//Message is a notification with its data decoded into the payload type T
+type Message[T any] struct {
+	Metadata     pojo.Metadata
+	Notification pojo.Notification
+	Data         T
+}
+
+//TypedProcessor processes the notifications of a topic with their data decoded into T
+type TypedProcessor[T any] interface {
+	Process(ctx context.Context, message *Message[T]) error
+}
+
+//TypedProcessorFunc is an adapter to use an ordinary function as a TypedProcessor
+type TypedProcessorFunc[T any] func(ctx context.Context, message *Message[T]) error
+
+//Process calls f(ctx, message)
+func (f TypedProcessorFunc[T]) Process(ctx context.Context, message *Message[T]) error {
+	return f(ctx, message)
+}
+
+//typedProcessor adapts a TypedProcessor to the ContextProcessor interface
+type typedProcessor[T any] struct {
+	processor TypedProcessor[T]
+}
+
+func (t typedProcessor[T]) Process(ctx context.Context, message *pojo.Message) error {
+	typed := &Message[T]{Metadata: message.Metadata, Notification: message.Notification}
+	if err := DecodeData(message, &typed.Data); err != nil {
+		// redeliveries carry the same data
+		return Permanent(err)
+	}
+	return t.processor.Process(ctx, typed)
+}
+
+//Typed is used to convert a TypedProcessor into a ContextProcessor decoding the data of the
+//notifications into T. Data which does not match T fails with a permanent *PayloadError.
+//Input
+//	p - typed processor
+//Returns
+//	context aware processor, to be registered with Registry.RegisterContext, nil if p is nil
+func Typed[T any](p TypedProcessor[T]) ContextProcessor {
+	if isNil(p) {
+		return nil
+	}
+	return typedProcessor[T]{p}
+}
+
+//RegisterTyped is used to register a typed processor for a topic pattern in the DefaultRegistry
+func RegisterTyped[T any](topic string, p TypedProcessor[T]) error {
+	return RegisterContext(topic, Typed(p))
+}
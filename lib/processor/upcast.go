@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//Upcaster converts the data of a notification from an older schema version into a newer one
+type Upcaster func(data json.RawMessage) (json.RawMessage, error)
+
+//UpcastFunc is used to build an Upcaster from a function converting payload types
+//Input
+//	f - conversion of the older payload into the newer one
+//Returns
+//	upcaster decoding the data into From and encoding the result of f
+func UpcastFunc[From any, To any](f func(From) (To, error)) Upcaster {
+	return func(data json.RawMessage) (json.RawMessage, error) {
+		var from From
+		if err := json.Unmarshal(data, &from); err != nil {
+			return nil, err
+		}
+		to, err := f(from)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(to)
+	}
+}
+
+//UpcastError is returned when an upcaster fails to convert the data of a notification
+type UpcastError struct {
+	Topic string
+	From  string
+	To    string
+	Err   error
+}
+
+func (u *UpcastError) Error() string {
+	return fmt.Sprintf("upcasting %s notification from schema version %s to %s: %v", u.Topic, u.From, u.To, u.Err)
+}
+
+func (u *UpcastError) Unwrap() error {
+	return u.Err
+}
+
+//upcaster converts the schema versions in a range to a target version
+type upcaster struct {
+	from     VersionRange
+	to       string
+	upcaster Upcaster
+}
+
+//upcastProcessor runs the upcasters on a copy of the message before passing it on
+type upcastProcessor struct {
+	steps     []upcaster
+	processor ContextProcessor
+}
+
+func (u upcastProcessor) Process(ctx context.Context, message *pojo.Message) error {
+	upcast := *message
+	for _, step := range u.steps {
+		data, err := step.upcaster(upcast.Notification.Data)
+		if err != nil {
+			// the same data fails again on redelivery
+			return Permanent(&UpcastError{Topic: upcast.Metadata.Topic, From: upcast.Metadata.SchemaVersion, To: step.to, Err: err})
+		}
+		upcast.Notification.Data = data
+		upcast.Metadata.SchemaVersion = step.to
+	}
+	return u.processor.Process(ctx, &upcast)
+}
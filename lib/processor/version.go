@@ -0,0 +1,151 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package processor
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//AnyVersion is the version range matching every schema version
+const AnyVersion = "x"
+
+//VersionRange is a range of schema versions, e.g. "1.x" for every 1 version,
+//"1.2" for exactly 1.2 (and 1.2.0), or "x" for any version
+type VersionRange struct {
+	raw string
+	//fixed are the numeric components before the wildcard
+	fixed []int
+	//open is set when the range ends with a wildcard
+	open bool
+}
+
+//ParseVersionRange is used to parse a version range
+//Input
+//	versions - dot separated numbers, optionally ending with "x" or "*", spaces are ignored
+//Returns
+//	version range
+//	error when the range is malformed
+func ParseVersionRange(versions string) (VersionRange, error) {
+	r := VersionRange{raw: versions}
+	parts := strings.Split(strings.TrimSpace(versions), ".")
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == AnyVersion || part == Wildcard {
+			if i != len(parts)-1 {
+				return VersionRange{}, fmt.Errorf("version range %q: wildcard must be the last component", versions)
+			}
+			r.open = true
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return VersionRange{}, fmt.Errorf("version range %q: invalid component %q", versions, part)
+		}
+		r.fixed = append(r.fixed, n)
+	}
+	return r, nil
+}
+
+//Contains reports whether a schema version is in the range.
+//Missing trailing components count as zero, a malformed version only matches "x".
+func (r VersionRange) Contains(version string) bool {
+	components, ok := parseVersion(version)
+	if !ok {
+		return r.open && len(r.fixed) == 0
+	}
+	for i, n := range r.fixed {
+		if i < len(components) {
+			if components[i] != n {
+				return false
+			}
+		} else if n != 0 {
+			return false
+		}
+	}
+	if r.open {
+		return true
+	}
+	for i := len(r.fixed); i < len(components); i++ {
+		if components[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (r VersionRange) String() string {
+	return r.raw
+}
+
+//Equal reports whether two ranges are the same once parsed, e.g. "1.x" and "1.*"
+func (r VersionRange) Equal(other VersionRange) bool {
+	if r.open != other.open || len(r.fixed) != len(other.fixed) {
+		return false
+	}
+	for i, n := range r.fixed {
+		if other.fixed[i] != n {
+			return false
+		}
+	}
+	return true
+}
+
+//Overlaps reports whether two different ranges contain the same versions without one of them being
+//more specific, e.g. "1" and "1.0". Nested ranges such as "1.x" and "1.2" do not overlap.
+func (r VersionRange) Overlaps(other VersionRange) bool {
+	if r.open || other.open || r.Equal(other) {
+		return false
+	}
+	return r.Contains(other.version()) && other.Contains(r.version())
+}
+
+//Returns how specific the range is, exact ranges rank above every open one and open ranges
+//rank by the length of their prefix
+func (r VersionRange) specificity() int {
+	if r.open {
+		return len(r.fixed)
+	}
+	return math.MaxInt32
+}
+
+//Returns the lowest version of the range
+func (r VersionRange) version() string {
+	parts := make([]string, len(r.fixed))
+	for i, n := range r.fixed {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+//Split a schema version into its numeric components
+func parseVersion(version string) ([]int, bool) {
+	if version == "" {
+		return nil, false
+	}
+	var components []int
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		components = append(components, n)
+	}
+	return components, true
+}
@@ -0,0 +1,303 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package service
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	//DefaultCacheSize is the number of public keys kept by a key cache
+	DefaultCacheSize = 100
+	//DefaultKeyTTL is how long a fetched public key is cached
+	DefaultKeyTTL = time.Hour
+	//DefaultNegativeTTL is how long an unknown key id is remembered
+	DefaultNegativeTTL = 30 * time.Second
+	//DefaultFetchTimeout bounds a lookup shared by the callers waiting for a key id
+	DefaultFetchTimeout = 10 * time.Second
+)
+
+//KeyCache caches parsed public keys by key id.
+//Keys expire after a TTL, unknown key ids are remembered for a short negative TTL so they
+//do not hit the provider on every request, and concurrent misses for the same key id
+//share a single lookup bounded by a timeout. Transient failures are not cached.
+type KeyCache struct {
+	entries      *lru.Cache
+	ttl          time.Duration
+	negativeTTL  time.Duration
+	fetchTimeout time.Duration
+	now          func() time.Time
+
+	mu       sync.Mutex
+	inflight map[string]*keyCall
+
+	hits         uint64
+	misses       uint64
+	negativeHits uint64
+	evictions    uint64
+}
+
+//KeyCacheStats are the counters of a KeyCache
+type KeyCacheStats struct {
+	//Hits is the number of lookups served with a cached key
+	Hits uint64
+	//NegativeHits is the number of lookups served with a cached unknown key id
+	NegativeHits uint64
+	//Misses is the number of lookups sent to the provider
+	Misses uint64
+	//Evictions is the number of entries dropped to make room for new ones
+	Evictions uint64
+	//Size is the number of cached entries, including expired ones
+	Size int
+}
+
+//keyEntry is a cached lookup result
+type keyEntry struct {
+	info    *KeyInfo
+	err     error
+	expires time.Time
+}
+
+//keyCall is a lookup shared by concurrent callers
+type keyCall struct {
+	done chan struct{}
+	info *KeyInfo
+	err  error
+}
+
+//KeyCacheOption configures a KeyCache
+type KeyCacheOption func(*KeyCache)
+
+//WithKeyTTL sets how long a fetched public key is cached. Default: DefaultKeyTTL
+func WithKeyTTL(ttl time.Duration) KeyCacheOption {
+	return func(c *KeyCache) {
+		c.ttl = ttl
+	}
+}
+
+//WithNegativeTTL sets how long a key id unknown to the provider (ErrKeyNotFound) is remembered,
+//zero disables negative caching. Default: DefaultNegativeTTL
+func WithNegativeTTL(ttl time.Duration) KeyCacheOption {
+	return func(c *KeyCache) {
+		c.negativeTTL = ttl
+	}
+}
+
+//WithFetchTimeout sets how long a lookup sent to the provider may take, zero disables the timeout.
+//Default: DefaultFetchTimeout
+func WithFetchTimeout(timeout time.Duration) KeyCacheOption {
+	return func(c *KeyCache) {
+		c.fetchTimeout = timeout
+	}
+}
+
+//WithKeyCacheClock sets the function returning the current time. Default: time.Now
+func WithKeyCacheClock(now func() time.Time) KeyCacheOption {
+	return func(c *KeyCache) {
+		c.now = now
+	}
+}
+
+//DefaultKeyCache is the key cache shared by the package level functions
+var DefaultKeyCache = newDefaultKeyCache()
+
+func newDefaultKeyCache() *KeyCache {
+	cache, err := NewKeyCache(DefaultCacheSize)
+	if err != nil {
+		// only returned for a non positive size
+		panic(err)
+	}
+	return cache
+}
+
+//NewKeyCache is used to create a key cache
+//Input
+//	size - maximum number of cached key ids
+//	opts - options overriding the defaults
+//Returns
+//	key cache
+//	error for a non positive size
+func NewKeyCache(size int, opts ...KeyCacheOption) (*KeyCache, error) {
+	cache := &KeyCache{
+		ttl:          DefaultKeyTTL,
+		negativeTTL:  DefaultNegativeTTL,
+		fetchTimeout: DefaultFetchTimeout,
+		now:          time.Now,
+		inflight:     make(map[string]*keyCall),
+	}
+	entries, err := lru.NewWithEvict(size, func(key interface{}, value interface{}) {
+		atomic.AddUint64(&cache.evictions, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	cache.entries = entries
+	for _, opt := range opts {
+		opt(cache)
+	}
+	return cache, nil
+}
+
+//Provider is used to wrap a key provider with this cache
+//Input
+//	provider - key provider used on cache misses
+//Returns
+//	caching key provider, implementing KeyInfoProvider
+func (c *KeyCache) Provider(provider KeyProvider) KeyProvider {
+	return &cachedKeyProvider{cache: c, provider: provider}
+}
+
+//Stats is used to get the counters of the cache
+func (c *KeyCache) Stats() KeyCacheStats {
+	return KeyCacheStats{
+		Hits:         atomic.LoadUint64(&c.hits),
+		NegativeHits: atomic.LoadUint64(&c.negativeHits),
+		Misses:       atomic.LoadUint64(&c.misses),
+		Evictions:    atomic.LoadUint64(&c.evictions),
+		Size:         c.entries.Len(),
+	}
+}
+
+//Purge is used to drop every cached entry, e.g. after a key rotation
+func (c *KeyCache) Purge() {
+	c.entries.Purge()
+}
+
+//Dump is used to take a snapshot of the cached public keys fetched from the notification API,
+//e.g. to verify notifications offline. Keys of other providers are not included.
+func (c *KeyCache) Dump() KeyDump {
+	dump := make(KeyDump)
+	for _, kid := range c.entries.Keys() {
+		value, ok := c.entries.Peek(kid)
+		if !ok {
+			continue
+		}
+		if entry := value.(*keyEntry); entry.info != nil && entry.info.PEM != "" {
+			dump[kid.(string)] = pojo.Response{Key: entry.info.PEM, Algorithm: entry.info.Algorithm, Digest: entry.info.Digest}
+		}
+	}
+	return dump
+}
+
+//KeyDump holds public keys by key id as returned by the notification API, and is
+//marshalled to a JSON object. It is a key provider serving the dumped keys.
+type KeyDump map[string]pojo.Response
+
+//Key is used to get the public key of a key id
+func (d KeyDump) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	info, err := d.KeyInfo(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	return info.Key, nil
+}
+
+//KeyInfo is used to get the public key of a key id and the algorithm published with it
+func (d KeyDump) KeyInfo(ctx context.Context, kid string) (*KeyInfo, error) {
+	publicKey, ok := d[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+	key, err := ParsePublicKey(publicKey.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyInfo{Key: key, PEM: publicKey.Key, Algorithm: publicKey.Algorithm, Digest: publicKey.Digest}, nil
+}
+
+//Lookup a key id, calling the provider on a miss
+func (c *KeyCache) lookup(ctx context.Context, provider KeyProvider, kid string) (*KeyInfo, error) {
+	if value, ok := c.entries.Get(kid); ok {
+		entry := value.(*keyEntry)
+		if c.now().Before(entry.expires) {
+			if entry.err != nil {
+				atomic.AddUint64(&c.negativeHits, 1)
+				return nil, entry.err
+			}
+			atomic.AddUint64(&c.hits, 1)
+			return entry.info, nil
+		}
+	}
+
+	c.mu.Lock()
+	call, ok := c.inflight[kid]
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		call = &keyCall{done: make(chan struct{})}
+		c.inflight[kid] = call
+		go c.fetch(provider, kid, call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.info, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+//Fetch a key id from the provider and cache the result
+func (c *KeyCache) fetch(provider KeyProvider, kid string, call *keyCall) {
+	// the lookup is shared by all waiting callers, so it must not be bound to the context of one of them
+	ctx := context.Background()
+	if c.fetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.fetchTimeout)
+		defer cancel()
+	}
+	info, err := LookupKey(ctx, provider, kid)
+	if err == nil {
+		c.entries.Add(kid, &keyEntry{info: info, expires: c.now().Add(c.ttl)})
+	} else if c.negativeTTL > 0 && errors.Is(err, ErrKeyNotFound) {
+		// outages and timeouts are retried by the next request
+		c.entries.Add(kid, &keyEntry{err: err, expires: c.now().Add(c.negativeTTL)})
+	}
+
+	c.mu.Lock()
+	call.info, call.err = info, err
+	delete(c.inflight, kid)
+	c.mu.Unlock()
+	close(call.done)
+}
+
+//cachedKeyProvider serves keys of a provider through a KeyCache
+type cachedKeyProvider struct {
+	cache    *KeyCache
+	provider KeyProvider
+}
+
+func (p *cachedKeyProvider) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	info, err := p.cache.lookup(ctx, p.provider, kid)
+	if err != nil {
+		return nil, err
+	}
+	return info.Key, nil
+}
+
+func (p *cachedKeyProvider) KeyInfo(ctx context.Context, kid string) (*KeyInfo, error) {
+	return p.cache.lookup(ctx, p.provider, kid)
+}
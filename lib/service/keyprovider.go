@@ -0,0 +1,238 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	errs "github.com/ebay/event-notification-golang-sdk.git/lib/errs"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+//DefaultHTTPTimeout bounds the calls to the eBay APIs made with DefaultHTTPClient
+const DefaultHTTPTimeout = 10 * time.Second
+
+//DefaultHTTPClient calls the eBay APIs when no client is configured
+var DefaultHTTPClient = &http.Client{Timeout: DefaultHTTPTimeout}
+
+//KeyProvider resolves the public key used to verify notifications signed with a key id
+type KeyProvider interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+//KeyInfo is a parsed public key with the metadata published alongside it
+type KeyInfo struct {
+	Key crypto.PublicKey
+	//PEM is the key as returned by the notification API, empty for other providers
+	PEM       string
+	Algorithm string
+	Digest    string
+}
+
+//KeyInfoProvider is implemented by key providers knowing the algorithm and digest
+//published with a key, used to cross-check the X-EBAY-SIGNATURE header
+type KeyInfoProvider interface {
+	KeyInfo(ctx context.Context, kid string) (*KeyInfo, error)
+}
+
+//LookupKey is used to get the public key and, when the provider publishes it, its algorithm
+//Input
+//	ctx - context of the lookup
+//	keys - key provider
+//	kid - key id
+//Returns
+//	key info, with empty algorithm and digest for providers not implementing KeyInfoProvider
+//	error
+func LookupKey(ctx context.Context, keys KeyProvider, kid string) (*KeyInfo, error) {
+	if infos, ok := keys.(KeyInfoProvider); ok {
+		return infos.KeyInfo(ctx, kid)
+	}
+	key, err := keys.Key(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyInfo{Key: key}, nil
+}
+
+//RemoteKeyProvider fetches public keys from the eBay notification API.
+//Keys are not cached, wrap the provider with a KeyCache.
+type RemoteKeyProvider struct {
+	config     *pojo.CustomEnvironment
+	httpClient *http.Client
+	tokens     TokenSource
+	endpoint   string
+}
+
+//NewRemoteKeyProvider is used to create a key provider calling the notification API
+//Input
+//	config - specific custom environment holding the client credentials
+//	httpClient - client used for the API calls, DefaultHTTPClient when nil
+//Returns
+//	remote key provider using the SharedTokenSource of the environment unless WithTokenSource is used
+func NewRemoteKeyProvider(config *pojo.CustomEnvironment, httpClient *http.Client) *RemoteKeyProvider {
+	if httpClient == nil {
+		httpClient = DefaultHTTPClient
+	}
+	return &RemoteKeyProvider{config: config, httpClient: httpClient}
+}
+
+//Returns the token source authorizing the API calls
+func (r *RemoteKeyProvider) tokenSource() TokenSource {
+	if r.tokens != nil {
+		return r.tokens
+	}
+	return SharedTokenSource(r.config, r.httpClient)
+}
+
+//WithTokenSource is used to replace the token source authorizing the API calls
+//Input
+//	tokens - token source
+//Returns
+//	the key provider
+func (r *RemoteKeyProvider) WithTokenSource(tokens TokenSource) *RemoteKeyProvider {
+	r.tokens = tokens
+	return r
+}
+
+//WithEndpoint is used to replace the public key API of the environment, e.g. with a local fake server
+//Input
+//	endpoint - URL prefix the key id is appended to, the API of the environment when empty
+//Returns
+//	the key provider
+func (r *RemoteKeyProvider) WithEndpoint(endpoint string) *RemoteKeyProvider {
+	r.endpoint = endpoint
+	return r
+}
+
+//Key is used to get the parsed public key for a key id
+func (r *RemoteKeyProvider) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	info, err := r.KeyInfo(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	return info.Key, nil
+}
+
+//KeyInfo is used to get the parsed public key and the algorithm and digest published with it
+func (r *RemoteKeyProvider) KeyInfo(ctx context.Context, kid string) (*KeyInfo, error) {
+	publicKey, err := r.PublicKey(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParsePublicKey(publicKey.Key)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrKeyFetchFailed, err)
+	}
+	return &KeyInfo{Key: key, PEM: publicKey.Key, Algorithm: publicKey.Algorithm, Digest: publicKey.Digest}, nil
+}
+
+//PublicKey is used to get the public key response for a key id
+//Input
+//	ctx - context of the API calls
+//	kid - key id from the signature header
+//Returns
+//	public key response
+//	error wrapping errs.ErrKeyFetchFailed
+func (r *RemoteKeyProvider) PublicKey(ctx context.Context, kid string) (*pojo.Response, error) {
+
+	notifyEndpoint := r.endpoint
+	if notifyEndpoint == "" && r.config.Environment == constants.EnvironmentSandbox {
+		notifyEndpoint = constants.NotificationAPIEndpointSandbox
+	} else if notifyEndpoint == "" {
+		notifyEndpoint = constants.NotificationAPIEndpointProduction
+	}
+
+	tokens := r.tokenSource()
+	token, err := tokens.Token(ctx)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrKeyFetchFailed, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, constants.Get, notifyEndpoint+url.PathEscape(kid), nil)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrKeyFetchFailed, err)
+	}
+	req.Header.Add(constants.Authorization, constants.Bearer+token)
+	req.Header.Add(constants.ContentType, constants.ContentTypeApplication)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, errs.Wrap(errs.ErrKeyFetchFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		if invalidator, ok := tokens.(interface{ Invalidate() }); ok {
+			invalidator.Invalidate()
+		}
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errs.Wrap(errs.ErrKeyFetchFailed, fmt.Errorf("%w: %s", ErrKeyNotFound, kid))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errs.Wrap(errs.ErrKeyFetchFailed, fmt.Errorf("unexpected status %d for key %s", resp.StatusCode, kid))
+	}
+
+	var res pojo.Response
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, errs.Wrap(errs.ErrKeyFetchFailed, err)
+	}
+	if res.Key == "" {
+		return nil, errs.Wrap(errs.ErrKeyFetchFailed, fmt.Errorf("empty key %s", kid))
+	}
+
+	return &res, nil
+}
+
+//The format key function convert key by adding newline before/after comments
+//Input
+//	key - unformatted key
+//Returns
+//	key - formatted key
+func formatKey(key string) string {
+
+	key = strings.Replace(key, constants.KeyStart, fmt.Sprintf("%s\n", constants.KeyStart), 1)
+	key = strings.Replace(key, constants.KeyEnd, fmt.Sprintf("\n%s", constants.KeyEnd), 1)
+
+	return key
+}
+
+//ParsePublicKey is used to parse the PEM encoded public key returned by the notification API
+//Input
+//	publicKey - PEM key, with or without newlines around the armor
+//Returns
+//	parsed public key
+//	error
+func ParsePublicKey(publicKey string) (crypto.PublicKey, error) {
+	var pubPEMData = []byte(formatKey(publicKey))
+	block, _ := pem.Decode(pubPEMData)
+	if block == nil {
+		return nil, errors.New("Invalid PEM Block")
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package service
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+//ErrKeyNotFound is returned by key providers which do not know the key id
+var ErrKeyNotFound = errors.New("public key not found")
+
+//StaticKeyProvider serves public keys from memory, for pinned keys and offline tests
+type StaticKeyProvider struct {
+	mu   sync.RWMutex
+	keys map[string]crypto.PublicKey
+}
+
+//NewStaticKeyProvider is used to create a key provider serving the given keys
+//Input
+//	keys - public keys by key id, may be nil
+//Returns
+//	static key provider
+func NewStaticKeyProvider(keys map[string]crypto.PublicKey) *StaticKeyProvider {
+	provider := &StaticKeyProvider{keys: make(map[string]crypto.PublicKey, len(keys))}
+	for kid, key := range keys {
+		provider.keys[kid] = key
+	}
+	return provider
+}
+
+//Add is used to add or replace the public key of a key id
+func (s *StaticKeyProvider) Add(kid string, key crypto.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[kid] = key
+}
+
+//AddPEM is used to add the PEM encoded public key of a key id, as returned by the notification API
+//Input
+//	kid - key id
+//	publicKey - PEM encoded public key
+//Returns
+//	error when the key cannot be parsed
+func (s *StaticKeyProvider) AddPEM(kid string, publicKey string) error {
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	s.Add(kid, key)
+	return nil
+}
+
+//Key is used to get the public key of a key id
+func (s *StaticKeyProvider) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+	return key, nil
+}
+
+//PEMDirKeyProvider serves public keys stored as <kid>.pem files in a directory
+type PEMDirKeyProvider struct {
+	dir string
+}
+
+//NewPEMDirKeyProvider is used to create a key provider reading PEM files from a directory
+//Input
+//	dir - directory holding one <kid>.pem file per key
+//Returns
+//	PEM directory key provider
+func NewPEMDirKeyProvider(dir string) *PEMDirKeyProvider {
+	return &PEMDirKeyProvider{dir: dir}
+}
+
+//Key is used to read and parse the PEM file of a key id
+func (p *PEMDirKeyProvider) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if kid == "" || strings.ContainsAny(kid, `/\`) || strings.Contains(kid, "..") {
+		return nil, fmt.Errorf("%w: invalid key id %q", ErrKeyNotFound, kid)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(p.dir, kid+".pem"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	} else if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(string(data))
+}
+
+//ChainKeyProvider tries key providers in order and returns the first key found
+type ChainKeyProvider struct {
+	providers []KeyProvider
+}
+
+//NewChainKeyProvider is used to combine key providers, e.g. pinned keys before the notification API
+//Input
+//	providers - key providers in the order they are tried
+//Returns
+//	chain key provider
+func NewChainKeyProvider(providers ...KeyProvider) *ChainKeyProvider {
+	return &ChainKeyProvider{providers: providers}
+}
+
+//Key is used to get the public key from the first provider knowing the key id
+func (c *ChainKeyProvider) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	info, err := c.KeyInfo(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	return info.Key, nil
+}
+
+//KeyInfo is used to get the public key, and the algorithm published with it,
+//from the first provider knowing the key id.
+//The error matches ErrKeyNotFound only when every provider reported the key id as unknown,
+//otherwise it joins the failures of the other providers, e.g. timeouts, so it is retried.
+func (c *ChainKeyProvider) KeyInfo(ctx context.Context, kid string) (*KeyInfo, error) {
+	var failures []error
+	for _, provider := range c.providers {
+		info, err := LookupKey(ctx, provider, kid)
+		if err == nil {
+			return info, nil
+		}
+		if !errors.Is(err, ErrKeyNotFound) {
+			failures = append(failures, err)
+		}
+	}
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("public key %s: %w", kid, errors.Join(failures...))
+	}
+	return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+}
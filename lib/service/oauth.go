@@ -17,32 +17,63 @@
  package service
 
 import (
+	"context"
 	b64 "encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
-	lru "github.com/hashicorp/golang-lru"
+	"time"
 
 	constants "github.com/ebay/event-notification-golang-sdk.git/lib/constants"
+	logging "github.com/ebay/event-notification-golang-sdk.git/lib/logging"
 	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
 )
 
-var m = make(map[string]pojo.Environment)
-var cache, error = lru.New(100)
+//OAuthError is the error returned by the identity API when a token cannot be issued
+type OAuthError struct {
+	StatusCode  int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("oauth error %d %s: %s", e.StatusCode, e.Code, e.Description)
+	}
+	return fmt.Sprintf("oauth error %d %s", e.StatusCode, e.Code)
+}
+
+//appToken is an application access token and its expiry
+type appToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
 
 //Get App Token
 //Input
+//	ctx - context of the API call
+//	client - client used for the API call
 //	request config
 //Returns
-//	app token string
-func getAppToken(req *(pojo.CustomEnvironment)) string {
+//	app token
+//	error, an *OAuthError when the identity API rejects the request
+func getAppToken(ctx context.Context, client *http.Client, req *(pojo.CustomEnvironment)) (*appToken, error) {
 
 	var encodedStr string
 	encodedStr = constants.Basic + b64.URLEncoding.EncodeToString([]byte(req.ClientID+":"+req.ClientSecret))
 
-	u, _ := url.ParseRequestURI("https://" + req.BaseURL)
+	// a base URL with a scheme, e.g. of a local fake server, is used as is
+	baseURL := req.BaseURL
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "https://" + baseURL
+	}
+	u, err := url.ParseRequestURI(baseURL)
+	if err != nil {
+		return nil, err
+	}
 	u.Path = constants.IdentifyPath
 	urlStr := u.String()
 
@@ -50,17 +81,43 @@ func getAppToken(req *(pojo.CustomEnvironment)) string {
 	data.Set(constants.GrantType, constants.ClientCredentials)
 	data.Set(constants.Scope, constants.APIScope)
 
-	client := &http.Client{}
-	r, _ := http.NewRequest(constants.Post, urlStr, strings.NewReader(data.Encode()))
+	r, err := http.NewRequestWithContext(ctx, constants.Post, urlStr, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
 	r.Header.Add(constants.Authorization, encodedStr)
 	r.Header.Add(constants.ContentType, constants.ContentTypeApplication)
 
-	resp, _ := client.Do(r)
+	resp, err := client.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		oauthErr := &OAuthError{}
+		json.NewDecoder(resp.Body).Decode(oauthErr)
+		oauthErr.StatusCode = resp.StatusCode
+		return nil, oauthErr
+	}
 
-	var res map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&res)
+	var token appToken
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, &OAuthError{StatusCode: resp.StatusCode, Code: "invalid_response", Description: "missing access_token"}
+	}
+	if token.ExpiresIn <= 0 {
+		// a token without lifetime cannot be cached
+		return nil, &OAuthError{StatusCode: resp.StatusCode, Code: "invalid_response", Description: "missing expires_in"}
+	}
+	return &token, nil
+}
 
-	return fmt.Sprint(res[constants.AccessToken])
+//Returns the time at which a token issued at issuedAt expires
+func (t *appToken) expiry(issuedAt time.Time) time.Time {
+	return issuedAt.Add(time.Duration(t.ExpiresIn) * time.Second)
 }
 
 //GetPublicKey is used to get pblic key for provided config
@@ -68,35 +125,27 @@ func getAppToken(req *(pojo.CustomEnvironment)) string {
 //	keyId
 //	config details
 //Returns
-//	public key
+//	public key, empty when the key cannot be fetched
 func GetPublicKey(keyID string, config *pojo.CustomEnvironment) *pojo.Response {
-
-	publicKeyVal, isPresent := cache.Get(keyID)
-	if isPresent {
-		publicKey := publicKeyVal.(pojo.Response)
-		return &publicKey
+	publicKey, err := FetchPublicKey(keyID, config)
+	if err != nil {
+		logging.Default().Error("Failed to fetch public key", logging.KeyKid, keyID, logging.KeyError, err)
+		return &pojo.Response{}
 	}
+	return publicKey
+}
 
-	var notifyEndpoint string
-	if config.Environment == constants.EnvironmentSandbox {
-		notifyEndpoint = constants.NotificationAPIEndpointSandbox
-	} else {
-		notifyEndpoint = constants.NotificationAPIEndpointProduction
+//FetchPublicKey is used to get public key for provided config, reporting failures
+//Input
+//	keyId
+//	config details
+//Returns
+//	public key
+//	error wrapping errs.ErrKeyFetchFailed
+func FetchPublicKey(keyID string, config *pojo.CustomEnvironment) (*pojo.Response, error) {
+	info, err := LookupKey(context.Background(), DefaultKeyCache.Provider(NewRemoteKeyProvider(config, nil)), keyID)
+	if err != nil {
+		return nil, err
 	}
-
-	token := getAppToken(config)
-
-	client := &http.Client{}
-	r, _ := http.NewRequest(constants.Get, notifyEndpoint+keyID, nil)
-	r.Header.Add(constants.Authorization, constants.Bearer+token)
-	r.Header.Add(constants.ContentType, constants.ContentTypeApplication)
-
-	resp, _ := client.Do(r)
-
-	var res pojo.Response
-	json.NewDecoder(resp.Body).Decode(&res)
-
-	cache.Add(keyID, res)
-
-	return &res
+	return &pojo.Response{Key: info.PEM, Algorithm: info.Algorithm, Digest: info.Digest}, nil
 }
@@ -0,0 +1,223 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ *
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+const (
+	//DefaultRefreshBefore is how long before expiry a cached token is refreshed
+	DefaultRefreshBefore = 5 * time.Minute
+	//DefaultTokenTimeout bounds a token request shared by the waiting callers
+	DefaultTokenTimeout = 10 * time.Second
+)
+
+//TokenSource provides application access tokens for the eBay APIs
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+//ClientCredentialsTokenSource caches the client credentials token of an environment until it expires.
+//Once the token is within the refresh window it is renewed in the background while the current
+//token keeps being served, and concurrent callers share a single request to the identity API.
+//The refresh window is capped at half the lifetime of the token.
+type ClientCredentialsTokenSource struct {
+	config        *pojo.CustomEnvironment
+	httpClient    *http.Client
+	now           func() time.Time
+	refreshBefore time.Duration
+	timeout       time.Duration
+
+	mu        sync.Mutex
+	token     string
+	expiry    time.Time
+	refreshAt time.Time
+	inflight  *tokenCall
+}
+
+//tokenCall is a token request shared by concurrent callers
+type tokenCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+//TokenSourceOption configures a ClientCredentialsTokenSource
+type TokenSourceOption func(*ClientCredentialsTokenSource)
+
+//WithTokenClock sets the function returning the current time. Default: time.Now
+func WithTokenClock(now func() time.Time) TokenSourceOption {
+	return func(t *ClientCredentialsTokenSource) {
+		t.now = now
+	}
+}
+
+//WithRefreshBefore sets how long before expiry the token is refreshed. Default: DefaultRefreshBefore
+func WithRefreshBefore(refreshBefore time.Duration) TokenSourceOption {
+	return func(t *ClientCredentialsTokenSource) {
+		t.refreshBefore = refreshBefore
+	}
+}
+
+//WithTokenTimeout sets how long a request to the identity API may take, zero disables the timeout.
+//Default: DefaultTokenTimeout
+func WithTokenTimeout(timeout time.Duration) TokenSourceOption {
+	return func(t *ClientCredentialsTokenSource) {
+		t.timeout = timeout
+	}
+}
+
+//NewTokenSource is used to create a caching client credentials token source
+//Input
+//	config - specific custom environment holding the client credentials
+//	httpClient - client used for the API calls, DefaultHTTPClient when nil
+//	opts - options overriding the defaults
+//Returns
+//	token source
+func NewTokenSource(config *pojo.CustomEnvironment, httpClient *http.Client, opts ...TokenSourceOption) *ClientCredentialsTokenSource {
+	if httpClient == nil {
+		httpClient = DefaultHTTPClient
+	}
+	source := &ClientCredentialsTokenSource{
+		config:        config,
+		httpClient:    httpClient,
+		now:           time.Now,
+		refreshBefore: DefaultRefreshBefore,
+		timeout:       DefaultTokenTimeout,
+	}
+	for _, opt := range opts {
+		opt(source)
+	}
+	return source
+}
+
+//Token is used to get a valid application token, fetching one only when needed
+//Input
+//	ctx - context of the API call
+//Returns
+//	access token
+//	error, an *OAuthError when the identity API rejects the request
+func (t *ClientCredentialsTokenSource) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	now := t.now()
+	if t.token != "" && now.Before(t.expiry) {
+		token := t.token
+		if !now.Before(t.refreshAt) && t.inflight == nil {
+			// refresh early without blocking the caller on the still valid token
+			t.refresh()
+		}
+		t.mu.Unlock()
+		return token, nil
+	}
+	call := t.inflight
+	if call == nil {
+		call = t.refresh()
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+//Invalidate is used to drop the cached token, e.g. after the API rejected it
+func (t *ClientCredentialsTokenSource) Invalidate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.token = ""
+	t.expiry = time.Time{}
+	t.refreshAt = time.Time{}
+}
+
+//Start fetching a new token, must be called with t.mu held
+func (t *ClientCredentialsTokenSource) refresh() *tokenCall {
+	call := &tokenCall{done: make(chan struct{})}
+	t.inflight = call
+	go func() {
+		issuedAt := t.now()
+		// the request is shared by all waiting callers, so it must not be bound to the context of one of them
+		ctx := context.Background()
+		if t.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, t.timeout)
+			defer cancel()
+		}
+		token, err := getAppToken(ctx, t.httpClient, t.config)
+
+		t.mu.Lock()
+		if err == nil {
+			t.token = token.AccessToken
+			t.expiry = token.expiry(issuedAt)
+			// a short lived token is still served for half its lifetime before being refreshed
+			refreshBefore := t.refreshBefore
+			if lifetime := t.expiry.Sub(issuedAt); refreshBefore > lifetime/2 {
+				refreshBefore = lifetime / 2
+			}
+			t.refreshAt = t.expiry.Add(-refreshBefore)
+			call.token = token.AccessToken
+		} else {
+			call.err = err
+		}
+		t.inflight = nil
+		t.mu.Unlock()
+		close(call.done)
+	}()
+	return call
+}
+
+//tokenSourceKey identifies the credentials of a shared token source
+type tokenSourceKey struct {
+	environment  string
+	baseURL      string
+	clientID     string
+	clientSecret string
+}
+
+var (
+	sharedTokenSourcesMu sync.Mutex
+	sharedTokenSources   = map[tokenSourceKey]*ClientCredentialsTokenSource{}
+)
+
+//SharedTokenSource is used to get the token source shared by every caller using the same
+//environment and credentials, so the token is requested once per environment.
+//Long lived clients should own a token source created with NewTokenSource instead.
+//Input
+//	config - specific custom environment holding the client credentials
+//	httpClient - client used for the API calls by a new token source, DefaultHTTPClient when nil
+//Returns
+//	token source
+func SharedTokenSource(config *pojo.CustomEnvironment, httpClient *http.Client) *ClientCredentialsTokenSource {
+	key := tokenSourceKey{config.Environment, config.BaseURL, config.ClientID, config.ClientSecret}
+
+	sharedTokenSourcesMu.Lock()
+	defer sharedTokenSourcesMu.Unlock()
+	source, ok := sharedTokenSources[key]
+	if !ok {
+		source = NewTokenSource(config, httpClient)
+		sharedTokenSources[key] = source
+	}
+	return source
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	chiadapter "github.com/ebay/event-notification-golang-sdk.git/adapters/chi"
+	echoadapter "github.com/ebay/event-notification-golang-sdk.git/adapters/echo"
+	fiberadapter "github.com/ebay/event-notification-golang-sdk.git/adapters/fiber"
+	ginadapter "github.com/ebay/event-notification-golang-sdk.git/adapters/gin"
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/chi/v5"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+)
+
+//adapters build a router serving the webhook endpoint on /webhook with each framework
+var adapters = map[string]func(config *pojo.Config, opts ...sdk.Option) http.Handler{
+	"net/http": func(config *pojo.Config, opts ...sdk.Option) http.Handler {
+		mux := http.NewServeMux()
+		mux.Handle("/webhook", sdk.Handler(config, opts...))
+		return mux
+	},
+	"gin": func(config *pojo.Config, opts ...sdk.Option) http.Handler {
+		gin.SetMode(gin.ReleaseMode)
+		router := gin.New()
+		ginadapter.Register(router, "/webhook", ginadapter.Handler(config, opts...))
+		return router
+	},
+	"echo": func(config *pojo.Config, opts ...sdk.Option) http.Handler {
+		router := echo.New()
+		echoadapter.Register(router, "/webhook", echoadapter.Handler(config, opts...))
+		return router
+	},
+	"chi": func(config *pojo.Config, opts ...sdk.Option) http.Handler {
+		router := chi.NewRouter()
+		chiadapter.Register(router, "/webhook", chiadapter.Handler(config, opts...))
+		return router
+	},
+	"fiber": func(config *pojo.Config, opts ...sdk.Option) http.Handler {
+		app := fiber.New(fiber.Config{DisableStartupMessage: true})
+		fiberadapter.Register(app, "/webhook", fiberadapter.Handler(config, opts...))
+		return fiberHandler{app}
+	},
+}
+
+//fiberHandler serves net/http requests with a fiber app, which does not implement http.Handler
+type fiberHandler struct {
+	app *fiber.App
+}
+
+func (f fiberHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := f.app.Test(r, -1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+//conformanceCase is a request and the response every adapter must give
+type conformanceCase struct {
+	name        string
+	method      string
+	target      string
+	body        []byte
+	signed      bool
+	status      int
+	contentType string
+}
+
+func TestAdapterConformance(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(context.Context, *pojo.Message) error {
+		return nil
+	}))
+	registry.RegisterContext("FAILING", processor.ProcessorFunc(func(context.Context, *pojo.Message) error {
+		return errors.New("database unavailable")
+	}))
+	large := bytes.Replace(notificationBody("ITEM_SOLD", "n-1"), []byte("test_user"), bytes.Repeat([]byte("x"), 2048), 1)
+
+	cases := []conformanceCase{
+		{name: "challenge", method: http.MethodGet, target: "/webhook?challenge_code=a8628072", status: http.StatusOK, contentType: "application/json"},
+		{name: "missing challenge code", method: http.MethodGet, target: "/webhook", status: http.StatusBadRequest},
+		{name: "processed", method: http.MethodPost, target: "/webhook", body: notificationBody("ITEM_SOLD", "n-1"), signed: true, status: http.StatusNoContent},
+		{name: "missing signature", method: http.MethodPost, target: "/webhook", body: notificationBody("ITEM_SOLD", "n-1"), status: http.StatusPreconditionFailed},
+		{name: "processor failure", method: http.MethodPost, target: "/webhook", body: notificationBody("FAILING", "n-1"), signed: true, status: http.StatusInternalServerError},
+		{name: "unknown topic", method: http.MethodPost, target: "/webhook", body: notificationBody("UNKNOWN", "n-1"), signed: true, status: http.StatusInternalServerError},
+		{name: "body too large", method: http.MethodPost, target: "/webhook", body: large, signed: true, status: http.StatusRequestEntityTooLarge},
+	}
+
+	var reference []byte
+	for name, adapter := range adapters {
+		handler := adapter(handlerConfig(), sdk.WithKeyProvider(keys), sdk.WithRegistry(registry),
+			sdk.WithLogger(quietLogger), sdk.WithMaxBodySize(1024))
+		for _, c := range cases {
+			req := httptest.NewRequest(c.method, c.target, bytes.NewReader(c.body))
+			if c.body != nil {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if c.signed {
+				req.Header.Set("X-EBAY-SIGNATURE", keys[testKid].SignRaw(t, c.body))
+			}
+			rec := serve(handler, req)
+			if rec.Code != c.status {
+				t.Errorf("%s %s: expected %d, got %d", name, c.name, c.status, rec.Code)
+			}
+			if c.contentType != "" && rec.Header().Get("Content-Type") != c.contentType {
+				t.Errorf("%s %s: expected content type %s, got %s", name, c.name, c.contentType, rec.Header().Get("Content-Type"))
+			}
+			if c.name == "challenge" {
+				if reference == nil {
+					reference = rec.Body.Bytes()
+				}
+				if !bytes.Equal(rec.Body.Bytes(), reference) {
+					t.Errorf("%s %s: unexpected body %s", name, c.name, rec.Body.Bytes())
+				}
+			}
+		}
+	}
+}
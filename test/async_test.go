@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+)
+
+func TestAsyncBackpressureAndDrain(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	started, release := make(chan struct{}, 10), make(chan struct{})
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}))
+	processed := make(chan string, 10)
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger),
+		sdk.WithAsync(sdk.WithWorkers(1), sdk.WithQueueSize(1), sdk.WithResultHandler(func(result *sdk.Result, err error) {
+			if err == nil {
+				processed <- result.NotificationID
+			}
+		})))
+	deliver := func(id string) (*sdk.Result, error) {
+		body := notificationBody("ITEM_SOLD", id)
+		return client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body))
+	}
+
+	if result, err := deliver("n-1"); err != nil || !result.Queued || result.HTTPStatus() != 204 {
+		t.Fatalf("expected notification to be queued, got %+v %v", result, err)
+	}
+	<-started
+	if _, err := deliver("n-2"); err != nil {
+		t.Fatalf("expected notification to fill the queue, got %v", err)
+	}
+	if _, err := deliver("n-3"); !errors.Is(err, sdk.ErrQueueFull) || sdk.HTTPStatus(err) != 429 {
+		t.Errorf("expected queue full, got %v", err)
+	}
+
+	// the invalid signature is still reported synchronously
+	body := notificationBody("ITEM_SOLD", "n-4")
+	if _, err := client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, []byte("other"))); !errors.Is(err, sdk.ErrInvalidSignature) {
+		t.Errorf("expected invalid signature, got %v", err)
+	}
+
+	close(release)
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Fatalf("expected queue to be drained, got %v", err)
+	}
+	close(processed)
+	var ids []string
+	for id := range processed {
+		ids = append(ids, id)
+	}
+	if len(ids) != 2 {
+		t.Errorf("expected queued notifications to be processed, got %v", ids)
+	}
+	if _, err := deliver("n-5"); !errors.Is(err, sdk.ErrShuttingDown) || sdk.HTTPStatus(err) != 503 {
+		t.Errorf("expected shutting down, got %v", err)
+	}
+}
+
+func TestAsyncTopicConcurrency(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	var mu sync.Mutex
+	running, peak := 0, 0
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	}))
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger),
+		sdk.WithAsync(sdk.WithWorkers(4), sdk.WithTopicConcurrency("ITEM_SOLD", 2)))
+
+	for i := 0; i < 10; i++ {
+		body := notificationBody("ITEM_SOLD", "n-"+strconv.Itoa(i))
+		if _, err := client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if peak != 2 {
+		t.Errorf("expected at most 2 concurrent notifications of the topic, got %d", peak)
+	}
+}
+
+func TestAsyncTopicConcurrencyDoesNotBlockOtherTopics(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	release := make(chan struct{})
+	processed := make(chan string, 1)
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		<-release
+		return nil
+	}))
+	registry.RegisterContext("ITEM_SHIPPED", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		processed <- message.Notification.NotificationID
+		return nil
+	}))
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger),
+		sdk.WithAsync(sdk.WithWorkers(2), sdk.WithTopicConcurrency("ITEM_SOLD", 1)))
+	defer client.Shutdown(context.Background())
+	defer close(release)
+
+	// the limited topic is saturated with a backlog
+	for i := 0; i < 5; i++ {
+		body := notificationBody("ITEM_SOLD", "n-"+strconv.Itoa(i))
+		if _, err := client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	body := notificationBody("ITEM_SHIPPED", "other")
+	if _, err := client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-processed:
+	case <-time.After(time.Second):
+		t.Errorf("expected the other topic to be processed while the limited one is saturated")
+	}
+}
+
+func TestAsyncShutdownDeadline(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	canceled := make(chan error, 1)
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		<-ctx.Done()
+		canceled <- ctx.Err()
+		return ctx.Err()
+	}))
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger), sdk.WithAsync())
+
+	body := notificationBody("ITEM_SOLD", "n-1")
+	client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := client.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected drain deadline, got %v", err)
+	}
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected processor context to be canceled, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+)
+
+var quietLogger = log.New(ioutil.Discard, "", 0)
+
+func TestClientProcessesWithOwnRegistry(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	var processed []string
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		processed = append(processed, message.Notification.NotificationID)
+		return nil
+	}))
+	fixed := time.Date(2021, 3, 19, 20, 44, 0, 0, time.UTC)
+
+	client, err := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry),
+		sdk.WithLogger(quietLogger), sdk.WithClock(func() time.Time { return fixed }))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	body := notificationBody("ITEM_SOLD", "n-1")
+	result, err := client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body))
+	if err != nil {
+		t.Fatalf("expected notification to be processed, got %v", err)
+	}
+	if len(processed) != 1 || processed[0] != "n-1" {
+		t.Errorf("expected processor to receive n-1, got %v", processed)
+	}
+	if result.Topic != "ITEM_SOLD" || !result.ReceivedAt.Equal(fixed) || result.HTTPStatus() != 204 {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	other, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(processor.NewRegistry()), sdk.WithLogger(quietLogger))
+	if _, err := other.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body)); !errors.Is(err, sdk.ErrUnknownTopic) {
+		t.Errorf("clients should not share registries, got %v", err)
+	}
+}
+
+func TestClientRejectsTamperedBody(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithLogger(quietLogger))
+
+	body := notificationBody("MARKETPLACE_ACCOUNT_DELETION", "n-1")
+	signature := keys[testKid].SignRaw(t, body)
+	tampered := notificationBody("MARKETPLACE_ACCOUNT_DELETION", "n-2")
+	if _, err := client.ValidateAndProcessRaw(context.Background(), tampered, signature); !errors.Is(err, sdk.ErrInvalidSignature) {
+		t.Errorf("expected invalid signature, got %v", err)
+	}
+}
+
+func TestClientProcessorErrors(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	failure := errors.New("database unavailable")
+	registry := processor.NewRegistry()
+	registry.RegisterContext("RETRY", processor.ProcessorFunc(func(context.Context, *pojo.Message) error {
+		return failure
+	}))
+	registry.RegisterContext("DROP", processor.ProcessorFunc(func(context.Context, *pojo.Message) error {
+		return processor.Permanent(failure)
+	}))
+	registry.RegisterContext("PANIC", processor.ProcessorFunc(func(context.Context, *pojo.Message) error {
+		panic("boom")
+	}))
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger))
+
+	body := notificationBody("RETRY", "n-1")
+	_, err := client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body))
+	if !errors.Is(err, sdk.ErrProcessorFailed) || !errors.Is(err, failure) || sdk.HTTPStatus(err) != 500 {
+		t.Errorf("expected retryable processor failure, got %v", err)
+	}
+
+	body = notificationBody("DROP", "n-2")
+	result, err := client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body))
+	if err != nil || !result.Dropped || sdk.HTTPStatus(err) != 204 {
+		t.Errorf("expected permanent failure to be dropped, got %+v %v", result, err)
+	}
+
+	body = notificationBody("PANIC", "n-3")
+	_, err = client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body))
+	var panicErr *processor.PanicError
+	if !errors.As(err, &panicErr) || sdk.HTTPStatus(err) != 500 {
+		t.Errorf("expected recovered panic, got %v", err)
+	}
+}
+
+func TestNewClientValidatesConfig(t *testing.T) {
+	if _, err := sdk.NewClient(nil); !errors.Is(err, sdk.ErrInvalidConfig) {
+		t.Errorf("expected invalid config for nil config, got %v", err)
+	}
+	if _, err := sdk.NewClient(&pojo.Config{}, sdk.WithEnvironment("QA")); !errors.Is(err, sdk.ErrInvalidConfig) {
+		t.Errorf("expected invalid config for unknown environment, got %v", err)
+	}
+}
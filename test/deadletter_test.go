@@ -0,0 +1,202 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	deadletter "github.com/ebay/event-notification-golang-sdk.git/lib/deadletter"
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+)
+
+var fastRetry = sdk.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond, Multiplier: 2}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := sdk.RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 3}
+	for retry, expected := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 300 * time.Millisecond, 3: 900 * time.Millisecond, 4: time.Second} {
+		if delay := policy.Backoff(retry); delay != expected {
+			t.Errorf("retry %d: expected %v, got %v", retry, expected, delay)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if delay := policy.Backoff(2); delay > 300*time.Millisecond || delay < 150*time.Millisecond {
+			t.Fatalf("jittered delay out of range: %v", delay)
+		}
+	}
+}
+
+func TestClientRetriesProcessor(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	calls := 0
+	registry := processor.NewRegistry()
+	registry.RegisterContext("FLAKY", processor.ProcessorFunc(func(context.Context, *pojo.Message) error {
+		calls++
+		if calls < 3 {
+			return errors.New("database unavailable")
+		}
+		return nil
+	}))
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry),
+		sdk.WithLogger(quietLogger), sdk.WithRetry(fastRetry))
+
+	body := notificationBody("FLAKY", "n-1")
+	if _, err := client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body)); err != nil || calls != 3 {
+		t.Errorf("expected success on the third attempt, got %v after %d calls", err, calls)
+	}
+
+	calls = -10
+	if _, err := client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body)); !errors.Is(err, sdk.ErrProcessorFailed) || calls != -7 {
+		t.Errorf("expected failure after 3 attempts, got %v after %d calls", err, calls+10)
+	}
+}
+
+func TestClientDeadLetters(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	broken := true
+	calls := 0
+	registry := processor.NewRegistry()
+	registry.RegisterContext("POISON", processor.ProcessorFunc(func(context.Context, *pojo.Message) error {
+		calls++
+		if broken {
+			return errors.New("cannot parse data")
+		}
+		return nil
+	}))
+	registry.RegisterContext("DROP", processor.ProcessorFunc(func(context.Context, *pojo.Message) error {
+		return processor.Permanent(errors.New("unsupported schema"))
+	}))
+	dir := t.TempDir()
+	files, err := deadletter.NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, store := range map[string]deadletter.Store{"memory": deadletter.NewMemoryStore(), "file": files} {
+		calls = 0
+		broken = true
+		client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry),
+			sdk.WithLogger(quietLogger), sdk.WithRetry(fastRetry), sdk.WithDeadLetterStore(store))
+		ctx := context.Background()
+
+		body := notificationBody("POISON", "n-1")
+		result, err := client.ValidateAndProcessRaw(ctx, body, keys[testKid].SignRaw(t, body))
+		if err != nil || result.DeadLetter == "" || calls != 3 || result.HTTPStatus() != 204 {
+			t.Fatalf("%s: expected notification to be dead lettered after 3 attempts, got %+v %v", name, result, err)
+		}
+		body = notificationBody("DROP", "n-2")
+		if dropped, err := client.ValidateAndProcessRaw(ctx, body, keys[testKid].SignRaw(t, body)); err != nil || dropped.DeadLetter == "" {
+			t.Fatalf("%s: expected permanent failure to be dead lettered, got %+v %v", name, dropped, err)
+		}
+
+		letters, err := client.DeadLetters(ctx)
+		if err != nil || len(letters) != 2 || letters[0].ID != result.DeadLetter {
+			t.Fatalf("%s: unexpected dead letters %v %v", name, letters, err)
+		}
+		letter, err := client.DeadLetter(ctx, result.DeadLetter)
+		if err != nil || letter.Topic != "POISON" || letter.NotificationID != "n-1" || letter.Error != "cannot parse data" ||
+			len(letter.Attempts) != 3 || letter.Attempts[2].Number != 3 || letter.Message.Notification.NotificationID != "n-1" {
+			t.Fatalf("%s: unexpected dead letter %+v %v", name, letter, err)
+		}
+		if letter, _ := client.DeadLetter(ctx, letters[1].ID); len(letter.Attempts) != 1 {
+			t.Errorf("%s: permanent errors should not be retried, got %+v", name, letter.Attempts)
+		}
+
+		if _, err := client.Redrive(ctx, letter.ID); !errors.Is(err, sdk.ErrProcessorFailed) {
+			t.Errorf("%s: expected redrive to fail, got %v", name, err)
+		}
+		if letter, _ := client.DeadLetter(ctx, letter.ID); len(letter.Attempts) != 6 || letter.Attempts[5].Number != 6 {
+			t.Errorf("%s: expected redrive attempts in the history, got %+v", name, letter.Attempts)
+		}
+		broken = false
+		if _, err := client.Redrive(ctx, letter.ID); err != nil {
+			t.Errorf("%s: expected redrive to succeed, got %v", name, err)
+		}
+		if _, err := client.DeadLetter(ctx, letter.ID); !errors.Is(err, deadletter.ErrNotFound) {
+			t.Errorf("%s: expected redriven letter to be removed, got %v", name, err)
+		}
+
+		if err := client.Discard(ctx, letters[1].ID); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if letters, _ := client.DeadLetters(ctx); len(letters) != 0 {
+			t.Errorf("%s: expected no dead letters left, got %d", name, len(letters))
+		}
+	}
+
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithLogger(quietLogger))
+	if _, err := client.DeadLetters(context.Background()); !errors.Is(err, sdk.ErrInvalidConfig) {
+		t.Errorf("expected invalid config without dead letter store, got %v", err)
+	}
+}
+
+func TestRetryStopsWhenContextDone(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	calls := 0
+	registry := processor.NewRegistry()
+	registry.RegisterContext("FLAKY", processor.ProcessorFunc(func(context.Context, *pojo.Message) error {
+		calls++
+		return errors.New("database unavailable")
+	}))
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger),
+		sdk.WithRetry(sdk.RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	body := notificationBody("FLAKY", "n-1")
+	if _, err := client.ValidateAndProcessRaw(ctx, body, keys[testKid].SignRaw(t, body)); !errors.Is(err, sdk.ErrProcessorFailed) || calls != 1 {
+		t.Errorf("expected retries to stop with the context, got %v after %d calls", err, calls)
+	}
+}
+
+func TestMemoryStoreCopiesLetters(t *testing.T) {
+	ctx := context.Background()
+	store := deadletter.NewMemoryStore()
+	letter := &deadletter.Letter{ID: "l-1", Message: &pojo.Message{Notification: pojo.Notification{Data: []byte(`{"a":1}`)}},
+		Attempts: []deadletter.Attempt{{Number: 1}}}
+	store.Put(ctx, letter)
+	letter.Attempts[0].Number = 9
+	letter.Message.Notification.Data[2] = 'b'
+
+	got, _ := store.Get(ctx, "l-1")
+	got.Attempts = append(got.Attempts, deadletter.Attempt{Number: 2})
+	got.Message.Metadata.Topic = "ITEM_SOLD"
+	listed, _ := store.List(ctx)
+	if len(listed) != 1 || len(listed[0].Attempts) != 1 || listed[0].Attempts[0].Number != 1 ||
+		listed[0].Message.Metadata.Topic != "" || string(listed[0].Message.Notification.Data) != `{"a":1}` {
+		t.Errorf("expected the stored letter to be unchanged, got %+v", listed[0])
+	}
+}
+
+func TestRedriveRejectsLetterWithoutMessage(t *testing.T) {
+	ctx := context.Background()
+	store := deadletter.NewMemoryStore()
+	store.Put(ctx, &deadletter.Letter{ID: "l-1", Topic: "ITEM_SOLD", NotificationID: "n-1"})
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(newTestKeys(t, testKid)), sdk.WithLogger(quietLogger),
+		sdk.WithDeadLetterStore(store))
+
+	if _, err := client.Redrive(ctx, "l-1"); !errors.Is(err, sdk.ErrInvalidDeadLetter) {
+		t.Errorf("expected dead letter without message to be rejected, got %v", err)
+	}
+	if _, err := client.DeadLetter(ctx, "l-1"); err != nil {
+		t.Errorf("expected dead letter to be kept, got %v", err)
+	}
+}
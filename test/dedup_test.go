@@ -0,0 +1,324 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dedup "github.com/ebay/event-notification-golang-sdk.git/lib/dedup"
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+//fakeRedis implements the SET, GET and DEL commands used by the Redis store, and EVAL of its
+//compare-and-delete and compare-and-set scripts, told apart by their number of arguments
+type fakeRedis struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func newFakeRedis(now func() time.Time) *fakeRedis {
+	return &fakeRedis{now: now, values: map[string]string{}, expires: map[string]time.Time{}}
+}
+
+func (r *fakeRedis) do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := args[1].(string)
+	if args[0] == "EVAL" {
+		key = args[3].(string)
+	}
+	if expires, ok := r.expires[key]; ok && !r.now().Before(expires) {
+		delete(r.values, key)
+		delete(r.expires, key)
+	}
+	switch args[0] {
+	case "GET":
+		if value, ok := r.values[key]; ok {
+			return []byte(value), nil
+		}
+		return nil, nil
+	case "DEL":
+		delete(r.values, key)
+		return int64(1), nil
+	case "EVAL":
+		value, ok := r.values[key]
+		if len(args) == 5 {
+			if !ok || value != args[4].(string) {
+				return int64(0), nil
+			}
+			delete(r.values, key)
+			return int64(1), nil
+		}
+		if ok && value != args[4].(string) {
+			return int64(0), nil
+		}
+		ms, _ := strconv.ParseInt(args[6].(string), 10, 64)
+		r.values[key] = args[5].(string)
+		r.expires[key] = r.now().Add(time.Duration(ms) * time.Millisecond)
+		return "OK", nil
+	case "SET":
+		var ttl time.Duration
+		nx := false
+		for i := 3; i < len(args); i++ {
+			switch args[i] {
+			case "NX":
+				nx = true
+			case "PX":
+				ms, _ := strconv.ParseInt(args[i+1].(string), 10, 64)
+				if ms <= 0 {
+					return nil, errors.New("ERR invalid expire time in 'set' command")
+				}
+				ttl = time.Duration(ms) * time.Millisecond
+				i++
+			}
+		}
+		if _, ok := r.values[key]; ok && nx {
+			return nil, nil
+		}
+		r.values[key] = args[2].(string)
+		r.expires[key] = r.now().Add(ttl)
+		return "OK", nil
+	}
+	return nil, errors.New("unsupported command")
+}
+
+//dedupStores creates each store with a clock controlled by the test
+func dedupStores(t *testing.T, now func() time.Time) map[string]dedup.Store {
+	files, err := dedup.NewFileStore(filepath.Join(t.TempDir(), "dedup"), dedup.WithClock(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "dedup.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	table := dedup.NewSQLStore(db, dedup.WithClock(now))
+	if err := table.CreateTable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]dedup.Store{
+		"memory": dedup.NewMemoryStore(dedup.WithClock(now)),
+		"file":   files,
+		"sql":    table,
+		"redis":  dedup.NewRedisStore(newFakeRedis(now).do),
+	}
+}
+
+func TestDedupStores(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	ctx := context.Background()
+
+	for name, store := range dedupStores(t, clock) {
+		var token string
+		expect := func(step string, expected dedup.State) {
+			t.Helper()
+			state, lock, err := store.Acquire(ctx, "n-1", time.Minute)
+			if err != nil || state != expected {
+				t.Errorf("%s %s: expected %s, got %s %v", name, step, expected, state, err)
+			}
+			if (lock != "") != (state == dedup.Acquired) {
+				t.Errorf("%s %s: expected a token only when acquired, got %q", name, step, lock)
+			}
+			if state == dedup.Acquired {
+				token = lock
+			}
+		}
+		expect("first delivery", dedup.Acquired)
+		expect("concurrent delivery", dedup.InProgress)
+
+		if err := store.Release(ctx, "n-1", token); err != nil {
+			t.Fatal(err)
+		}
+		expect("after release", dedup.Acquired)
+
+		now = now.Add(time.Minute)
+		expect("after lock timeout", dedup.Acquired)
+
+		if err := store.Complete(ctx, "n-1", token, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		expect("after completion", dedup.Done)
+		if err := store.Release(ctx, "n-1", token); err != nil {
+			t.Fatal(err)
+		}
+		expect("release after completion", dedup.Done)
+
+		now = now.Add(time.Hour)
+		expect("after retention", dedup.Acquired)
+
+		if state, _, err := store.Acquire(ctx, "n-2", time.Microsecond); err != nil || state != dedup.Acquired {
+			t.Errorf("%s: expected lock timeout under a millisecond to be accepted, got %s %v", name, state, err)
+		}
+	}
+}
+
+func TestDedupStoresStaleHolder(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	ctx := context.Background()
+
+	for name, store := range dedupStores(t, clock) {
+		_, stale, err := store.Acquire(ctx, "n-1", time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the first delivery hangs past the lock timeout and a redelivery takes the lock over
+		now = now.Add(time.Minute)
+		state, token, err := store.Acquire(ctx, "n-1", time.Minute)
+		if err != nil || state != dedup.Acquired || token == stale {
+			t.Fatalf("%s: expected expired lock to be taken over with a new token, got %s %v", name, state, err)
+		}
+
+		if err := store.Release(ctx, "n-1", stale); err != nil {
+			t.Fatal(err)
+		}
+		if state, _, _ := store.Acquire(ctx, "n-1", time.Minute); state != dedup.InProgress {
+			t.Errorf("%s: expected release by the stale holder to keep the lock, got %s", name, state)
+		}
+		if err := store.Complete(ctx, "n-1", stale, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		if state, _, _ := store.Acquire(ctx, "n-1", time.Minute); state != dedup.InProgress {
+			t.Errorf("%s: expected completion by the stale holder to keep the lock, got %s", name, state)
+		}
+
+		if err := store.Release(ctx, "n-1", token); err != nil {
+			t.Fatal(err)
+		}
+		if state, _, _ := store.Acquire(ctx, "n-1", time.Minute); state != dedup.Acquired {
+			t.Errorf("%s: expected release by the holder to unlock, got %s", name, state)
+		}
+	}
+}
+
+func TestFileStoreConcurrentTakeover(t *testing.T) {
+	now := time.Now()
+	var mu sync.Mutex
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	dir := t.TempDir()
+	ctx := context.Background()
+	first, _ := dedup.NewFileStore(dir, dedup.WithClock(clock))
+	if state, _, err := first.Acquire(ctx, "n-1", time.Minute); err != nil || state != dedup.Acquired {
+		t.Fatalf("expected first delivery to acquire, got %s %v", state, err)
+	}
+	mu.Lock()
+	now = now.Add(time.Minute)
+	mu.Unlock()
+
+	// instances sharing the directory race to take the expired lock over
+	var wg sync.WaitGroup
+	var acquired int32
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store, err := dedup.NewFileStore(dir, dedup.WithClock(clock))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			state, _, err := store.Acquire(ctx, "n-1", time.Minute)
+			if err != nil {
+				t.Error(err)
+			}
+			if state == dedup.Acquired {
+				atomic.AddInt32(&acquired, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if acquired != 1 {
+		t.Errorf("expected a single instance to take the lock over, got %d", acquired)
+	}
+}
+
+func TestClientDedup(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	started, release := make(chan struct{}), make(chan struct{})
+	var calls int32
+	var mu sync.Mutex
+	failures := 1
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if failures > 0 {
+			failures--
+			return errors.New("database unavailable")
+		}
+		return nil
+	}))
+	registry.RegisterContext("SLOW", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		close(started)
+		<-release
+		return nil
+	}))
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry),
+		sdk.WithLogger(quietLogger), sdk.WithDedupStore(dedup.NewMemoryStore()))
+	ctx := context.Background()
+
+	// a failed delivery is released for the retry
+	body := notificationBody("ITEM_SOLD", "n-1")
+	if _, err := client.ValidateAndProcessRaw(ctx, body, keys[testKid].SignRaw(t, body)); !errors.Is(err, sdk.ErrProcessorFailed) {
+		t.Fatalf("expected processor failure, got %v", err)
+	}
+	if result, err := client.ValidateAndProcessRaw(ctx, body, keys[testKid].SignRaw(t, body)); err != nil || result.Duplicate {
+		t.Fatalf("expected retry to be processed, got %+v %v", result, err)
+	}
+	result, err := client.ValidateAndProcessRaw(ctx, body, keys[testKid].SignRaw(t, body))
+	if err != nil || !result.Duplicate || result.HTTPStatus() != 204 {
+		t.Errorf("expected duplicate to be acknowledged, got %+v %v", result, err)
+	}
+	if calls != 2 {
+		t.Errorf("expected two processor calls, got %d", calls)
+	}
+
+	// a retry arriving while the first delivery is processed is rejected
+	slow := notificationBody("SLOW", "n-2")
+	done := make(chan error)
+	go func() {
+		_, err := client.ValidateAndProcessRaw(ctx, slow, keys[testKid].SignRaw(t, slow))
+		done <- err
+	}()
+	<-started
+	_, err = client.ValidateAndProcessRaw(ctx, slow, keys[testKid].SignRaw(t, slow))
+	if !errors.Is(err, sdk.ErrInProgress) || sdk.HTTPStatus(err) != 409 {
+		t.Errorf("expected concurrent delivery to be in progress, got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("expected first delivery to be processed, got %v", err)
+	}
+}
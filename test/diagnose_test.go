@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	helper "github.com/ebay/event-notification-golang-sdk.git/lib/helper"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	service "github.com/ebay/event-notification-golang-sdk.git/lib/service"
+)
+
+func failedStep(diagnosis *helper.Diagnosis) string {
+	for _, step := range diagnosis.Steps {
+		if !step.OK {
+			return step.Name
+		}
+	}
+	return ""
+}
+
+func TestDiagnose(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	body := notificationBody("ITEM_SOLD", "n-1")
+	signature := keys[testKid].SignRaw(t, body)
+
+	diagnosis := helper.Diagnose(context.Background(), body, signature, keys)
+	if diagnosis.Err != nil || diagnosis.MessageErr != nil || len(diagnosis.Steps) != 6 || failedStep(diagnosis) != "" || len(diagnosis.Hints) != 0 {
+		t.Fatalf("expected valid signature, got %+v", diagnosis)
+	}
+	if diagnosis.Header.Kid != testKid || diagnosis.Algorithm.String() != "ECDSA/SHA1" || diagnosis.Steps[1].Detail != "ECDSA P-256 key" {
+		t.Errorf("unexpected diagnosis %+v", diagnosis)
+	}
+
+	for name, test := range map[string]struct {
+		body      []byte
+		signature string
+		step      string
+		hint      bool
+	}{
+		"header":     {body, "not base64", helper.StepDecodeHeader, false},
+		"key":        {body, signatureFor(t, "unknown", signature), helper.StepFetchKey, false},
+		"whitespace": {append(append([]byte{}, body...), '\n'), signature, helper.StepVerifyRaw, true},
+		"tampered":   {notificationBody("ITEM_SOLD", "n-2"), signature, helper.StepVerifyRaw, true},
+		"re-encoded": {reencode(t, body, keys, &signature), signature, helper.StepVerifyRaw, true},
+	} {
+		diagnosis := helper.Diagnose(context.Background(), test.body, test.signature, keys)
+		if diagnosis.Err == nil || failedStep(diagnosis) != test.step || (len(diagnosis.Hints) > 0) != test.hint {
+			t.Errorf("%s: expected failure at %s, got %+v", name, test.step, diagnosis)
+		}
+	}
+}
+
+//signatureFor returns a signature header with its kid replaced
+func signatureFor(t *testing.T, kid string, header string) string {
+	decoded, err := helper.DecodeSignatureHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded.Kid = kid
+	data, _ := json.Marshal(decoded)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+//reencode returns the body with its keys reordered, and signs the re-marshalled message
+func reencode(t *testing.T, body []byte, keys testKeys, signature *string) []byte {
+	var message pojo.Message
+	if err := json.Unmarshal(body, &message); err != nil {
+		t.Fatal(err)
+	}
+	marshalled, _ := json.Marshal(&message)
+	*signature = keys[testKid].SignRaw(t, marshalled)
+	var fields map[string]interface{}
+	json.Unmarshal(body, &fields)
+	reordered, _ := json.MarshalIndent(fields, "", " ")
+	return reordered
+}
+
+func TestKeyCacheDump(t *testing.T) {
+	cache, err := service.NewKeyCache(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := cache.Provider(service.KeyDump{testKid: *response})
+	if _, err := provider.Key(context.Background(), testKid); err != nil {
+		t.Fatal(err)
+	}
+	provider.Key(context.Background(), "unknown")
+
+	data, err := json.Marshal(cache.Dump())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dump service.KeyDump
+	if err := json.Unmarshal(data, &dump); err != nil || len(dump) != 1 || dump[testKid] != *response {
+		t.Fatalf("unexpected dump %s %v", data, err)
+	}
+	info, err := service.LookupKey(context.Background(), dump, testKid)
+	if err != nil || info.Algorithm != response.Algorithm {
+		t.Errorf("expected dumped key info, got %+v %v", info, err)
+	}
+	loadTestData("VALID")
+	body, _ := json.Marshal(message)
+	if err := helper.VerifyRaw(context.Background(), body, signature, dump); err != nil {
+		t.Errorf("expected recorded notification to verify with the dump, got %v", err)
+	}
+}
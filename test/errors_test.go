@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	errs "github.com/ebay/event-notification-golang-sdk.git/lib/errs"
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+func TestTypedInputErrors(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"message", errorOf(sdk.ValidateAndProcessContext(ctx, nil, "signature", handlerConfig(), "PRODUCTION")), sdk.ErrMissingMessage},
+		{"body", errorOf(sdk.ValidateAndProcessRaw(ctx, nil, "signature", handlerConfig(), "PRODUCTION")), sdk.ErrMissingMessage},
+		{"signature", errorOf(sdk.ValidateAndProcessContext(ctx, &pojo.Message{}, "", handlerConfig(), "PRODUCTION")), sdk.ErrMissingSignature},
+		{"config", errorOf(sdk.ValidateAndProcessContext(ctx, &pojo.Message{}, "signature", nil, "PRODUCTION")), sdk.ErrInvalidConfig},
+		{"environment", errorOf(sdk.ValidateAndProcessRaw(ctx, []byte("{}"), "signature", handlerConfig(), "QA")), sdk.ErrInvalidConfig},
+	}
+	for _, c := range cases {
+		if !errors.Is(c.err, c.want) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, c.err)
+		}
+	}
+}
+
+func errorOf(_ *sdk.Result, err error) error {
+	return err
+}
+
+func TestChallengeResponseErrors(t *testing.T) {
+	if _, err := sdk.ChallengeResponse("", handlerConfig()); !errors.Is(err, sdk.ErrMissingChallengeCode) {
+		t.Errorf("expected missing challenge code, got %v", err)
+	}
+	if _, err := sdk.ChallengeResponse("code", &pojo.Config{}); !errors.Is(err, sdk.ErrInvalidConfig) {
+		t.Errorf("expected invalid config, got %v", err)
+	}
+	response, err := sdk.ChallengeResponse("a8628072-3d33-45ee-9004-bee86830a22d", handlerConfig())
+	if err != nil || len(response) != 64 {
+		t.Errorf("expected hex encoded sha256 response, got %q %v", response, err)
+	}
+}
+
+func TestHTTPStatusMapping(t *testing.T) {
+	cause := errors.New("cause")
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusNoContent},
+		{errs.Wrap(sdk.ErrInvalidSignature, cause), http.StatusPreconditionFailed},
+		{errs.Wrap(sdk.ErrInvalidMessage, cause), http.StatusBadRequest},
+		{errs.Wrap(sdk.ErrKeyFetchFailed, cause), http.StatusInternalServerError},
+		{errs.Wrap(sdk.ErrUnknownTopic, cause), http.StatusInternalServerError},
+		{errs.Wrap(sdk.ErrProcessorFailed, cause), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		if status := sdk.HTTPStatus(c.err); status != c.want {
+			t.Errorf("%v: expected %d, got %d", c.err, c.want, status)
+		}
+	}
+}
+
+func TestWrappedErrorKeepsCause(t *testing.T) {
+	cause := errors.New("database unavailable")
+	err := errs.Wrap(sdk.ErrProcessorFailed, cause)
+	if !errors.Is(err, sdk.ErrProcessorFailed) || !errors.Is(err, cause) {
+		t.Errorf("wrapped error should match both sentinel and cause")
+	}
+	if errors.Is(err, sdk.ErrUnknownTopic) {
+		t.Errorf("wrapped error should not match other sentinels")
+	}
+	if errs.Wrap(sdk.ErrProcessorFailed, nil) != nil {
+		t.Errorf("wrapping nil should return nil")
+	}
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	fakeebay "github.com/ebay/event-notification-golang-sdk.git/lib/fakeebay"
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+)
+
+func TestFakeEbayEndToEnd(t *testing.T) {
+	fake, err := fakeebay.StartTestServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+
+	var mu sync.Mutex
+	var received []*pojo.Message
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, message)
+		if len(received) == 1 {
+			return errors.New("database unavailable")
+		}
+		return nil
+	}))
+	client, err := sdk.NewClient(fake.Config(), append(fake.ClientOptions(), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := httptest.NewServer(client.Handler())
+	defer endpoint.Close()
+
+	message, err := fakeebay.NewMessage("ITEM_SOLD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := fake.Publisher(fakeebay.WithBackoff(time.Millisecond))
+	delivery, err := publisher.Publish(context.Background(), endpoint.URL, message)
+	if err != nil || !delivery.Delivered || len(delivery.Attempts) != 2 || delivery.Attempts[0].StatusCode != 500 || delivery.Attempts[1].StatusCode != 204 {
+		t.Fatalf("expected delivery on the second attempt, got %+v %v", delivery, err)
+	}
+	if len(received) != 2 || received[1].Notification.PublishAttemptCount != 2 || received[1].Notification.NotificationID != message.Notification.NotificationID {
+		t.Errorf("expected redelivery with publishAttemptCount 2, got %+v", received)
+	}
+	if stats := fake.Stats(); stats.TokenRequests != 1 || stats.KeyRequests != 1 {
+		t.Errorf("expected one token and one key request, got %+v", stats)
+	}
+
+	rotated, _ := fakeebay.GenerateKey("")
+	fake.AddKey(rotated)
+	if delivery, err := fake.Publisher().Publish(context.Background(), endpoint.URL, message); err != nil || len(delivery.Attempts) != 1 {
+		t.Errorf("expected notification signed with a rotated key to be delivered, got %+v %v", delivery, err)
+	}
+
+	stranger, _ := fakeebay.GenerateKey("")
+	delivery, err = fakeebay.NewPublisher(stranger, fakeebay.WithAttempts(2), fakeebay.WithBackoff(time.Millisecond)).Publish(context.Background(), endpoint.URL, message)
+	if !errors.Is(err, fakeebay.ErrNotDelivered) || len(delivery.Attempts) != 2 || delivery.Attempts[1].StatusCode != 500 {
+		t.Errorf("expected unknown key to fail the key lookup, got %+v %v", delivery, err)
+	}
+}
+
+func TestFakeEbayRejectsCredentials(t *testing.T) {
+	fake, err := fakeebay.StartTestServer(fakeebay.WithCredentials("id", "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+
+	config := fake.Config()
+	config.Production.ClientSecret = "wrong"
+	client, _ := sdk.NewClient(config, append(fake.ClientOptions(), sdk.WithLogger(quietLogger))...)
+	body := notificationBody("MARKETPLACE_ACCOUNT_DELETION", "n-1")
+	signature, _ := fake.Key().Sign(body)
+	if _, err := client.ValidateAndProcessRaw(context.Background(), body, signature); !errors.Is(err, sdk.ErrKeyFetchFailed) {
+		t.Errorf("expected key fetch to fail with wrong credentials, got %v", err)
+	}
+
+	client, _ = sdk.NewClient(fake.Config(), append(fake.ClientOptions(), sdk.WithLogger(quietLogger))...)
+	if _, err := client.ValidateAndProcessRaw(context.Background(), body, signature); err != nil {
+		t.Errorf("expected notification to verify, got %v", err)
+	}
+}
+
+func TestFakeEbayKeyFile(t *testing.T) {
+	key, err := fakeebay.GenerateKey("kid-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := key.MarshalPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := fakeebay.ParseKey(data)
+	if err != nil || parsed.ID != "kid-1" || !parsed.Private.Equal(key.Private) {
+		t.Errorf("expected key to round trip, got %+v %v", parsed, err)
+	}
+
+	body := []byte(`{"metadata":{}}`)
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-EBAY-SIGNATURE") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer endpoint.Close()
+	if delivery, err := fakeebay.NewPublisher(parsed).PublishRaw(context.Background(), endpoint.URL, body); err != nil || !delivery.Delivered {
+		t.Errorf("expected raw body to be delivered with eBay headers, got %+v %v", delivery, err)
+	}
+}
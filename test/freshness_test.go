@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	dedup "github.com/ebay/event-notification-golang-sdk.git/lib/dedup"
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+)
+
+//publishDate of notificationBody
+var published = time.Date(2021, 3, 19, 20, 43, 59, 679000000, time.UTC)
+
+func TestReplayProtection(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	registry := processor.NewRegistry()
+	processed := 0
+	registry.SetDefaultContext(processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		processed++
+		return nil
+	}))
+	now := published.Add(5 * time.Minute)
+	signatures := dedup.NewMemoryStore(dedup.WithClock(func() time.Time { return now }))
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger),
+		sdk.WithClock(func() time.Time { return now }), sdk.WithSignatureStore(signatures), sdk.WithReplayProtection(sdk.DefaultFreshnessPolicy))
+	ctx := context.Background()
+
+	body := notificationBody("ITEM_SOLD", "n-1")
+	signature := keys[testKid].SignRaw(t, body)
+	if _, err := client.ValidateAndProcessRaw(ctx, body, signature); err != nil {
+		t.Fatalf("expected fresh notification to be processed, got %v", err)
+	}
+	_, err := client.ValidateAndProcessRaw(ctx, body, signature)
+	if !errors.Is(err, sdk.ErrReplayedMessage) || sdk.HTTPStatus(err) != http.StatusPreconditionFailed {
+		t.Errorf("expected replayed signature to be rejected, got %v", err)
+	}
+	var message pojo.Message
+	json.Unmarshal(body, &message)
+	if _, err := client.ValidateAndProcess(ctx, &message, signature); !errors.Is(err, sdk.ErrReplayedMessage) {
+		t.Errorf("expected replayed decoded message to be rejected, got %v", err)
+	}
+	if processed != 1 || signatures.Len() != 1 {
+		t.Errorf("expected one processed notification and seen signature, got %d and %d", processed, signatures.Len())
+	}
+
+	// the signature is forgotten once the notification is stale
+	now = published.Add(20 * time.Minute)
+	if _, err := client.ValidateAndProcessRaw(ctx, body, signature); !errors.Is(err, sdk.ErrStaleMessage) {
+		t.Errorf("expected stale notification to be rejected, got %v", err)
+	}
+	redelivery := []byte(strings.Replace(string(body), `"publishAttemptCount":1`, `"publishAttemptCount":2`, 1))
+	if _, err := client.ValidateAndProcessRaw(ctx, redelivery, keys[testKid].SignRaw(t, redelivery)); err != nil {
+		t.Errorf("expected redelivery to be allowed the retry allowance, got %v", err)
+	}
+
+	now = published.Add(-5 * time.Minute)
+	future := notificationBody("ITEM_SOLD", "n-2")
+	if _, err := client.ValidateAndProcessRaw(ctx, future, keys[testKid].SignRaw(t, future)); !errors.Is(err, sdk.ErrStaleMessage) {
+		t.Errorf("expected notification from the future to be rejected, got %v", err)
+	}
+	undated := []byte(strings.Replace(string(body), `"publishDate":"2021-03-19T20:43:59.679Z"`, `"publishDate":"yesterday"`, 1))
+	if _, err := client.ValidateAndProcessRaw(ctx, undated, keys[testKid].SignRaw(t, undated)); !errors.Is(err, sdk.ErrStaleMessage) {
+		t.Errorf("expected notification without publish date to be rejected, got %v", err)
+	}
+	if processed != 2 {
+		t.Errorf("expected rejected notifications not to be processed, got %d", processed)
+	}
+}
@@ -0,0 +1,207 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	fakeebay "github.com/ebay/event-notification-golang-sdk.git/lib/fakeebay"
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	"github.com/ebay/event-notification-golang-sdk.git/lib/notification/notificationtest"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+)
+
+func handlerConfig() *pojo.Config {
+	env := pojo.Environment{ClientID: "clientId", ClientSecret: "clientSecret", BaseURL: "api.ebay.com"}
+	return &pojo.Config{Sandbox: env, Production: env, Endpoint: "https://www.testendpoint.com/webhook", VerificationToken: "71745723-d031-455c-bfa5-f90d11b4f20a"}
+}
+
+func serve(handler http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerRejectsOtherMethods(t *testing.T) {
+	rec := serve(sdk.Handler(handlerConfig(), sdk.WithEnvironment("PRODUCTION")), httptest.NewRequest(http.MethodPut, "/webhook", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestHandlerMissingSignature(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"metadata":{}}`))
+	rec := serve(sdk.Handler(handlerConfig(), sdk.WithEnvironment("PRODUCTION")), req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412, got %d", rec.Code)
+	}
+}
+
+func TestHandlerEmptyBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", nil)
+	req.Header.Set("X-EBAY-SIGNATURE", "signature")
+	rec := serve(sdk.Handler(handlerConfig(), sdk.WithEnvironment("PRODUCTION")), req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestHandlerMalformedSignature(t *testing.T) {
+	for _, header := range []string{"not base64!", "bm90IGpzb24=", "e30="} {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"metadata":{}}`))
+		req.Header.Set("X-EBAY-SIGNATURE", header)
+		rec := serve(sdk.Handler(handlerConfig(), sdk.WithEnvironment("PRODUCTION")), req)
+		if rec.Code != http.StatusPreconditionFailed {
+			t.Errorf("%q: expected 412, got %d", header, rec.Code)
+		}
+	}
+}
+
+func TestHandlerChallenge(t *testing.T) {
+	handler := sdk.Handler(handlerConfig())
+	rec := serve(handler, httptest.NewRequest(http.MethodGet, "/webhook?challenge_code=a8628072-3d33-45ee-9004-bee86830a22d", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected 200 json, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	config := handlerConfig()
+	expected := sha256.Sum256([]byte("a8628072-3d33-45ee-9004-bee86830a22d" + config.VerificationToken + config.Endpoint))
+	if body["challengeResponse"] != hex.EncodeToString(expected[:]) {
+		t.Errorf("unexpected challenge response %s", body["challengeResponse"])
+	}
+
+	rec = serve(handler, httptest.NewRequest(http.MethodGet, "/webhook", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without challenge code, got %d", rec.Code)
+	}
+	rec = serve(sdk.Handler(&pojo.Config{}), httptest.NewRequest(http.MethodGet, "/webhook?challenge_code=code", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 without verification token, got %d", rec.Code)
+	}
+}
+
+func TestHandlerProcessesNotification(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	registry := processor.NewRegistry()
+	registry.RegisterContext("SLOW", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(context.Context, *pojo.Message) error {
+		return nil
+	}))
+	handler := sdk.Handler(handlerConfig(), sdk.WithKeyProvider(keys), sdk.WithRegistry(registry),
+		sdk.WithLogger(quietLogger), sdk.WithRequestTimeout(10*time.Millisecond), sdk.WithMaxBodySize(1024))
+
+	post := func(body []byte, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-EBAY-SIGNATURE", keys[testKid].SignRaw(t, body))
+		return serve(handler, req)
+	}
+
+	if rec := post(notificationBody("ITEM_SOLD", "n-1"), "application/json; charset=utf-8"); rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rec.Code)
+	}
+	if rec := post(notificationBody("SLOW", "n-2"), "application/json"); rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 after timeout, got %d", rec.Code)
+	}
+	if rec := post(notificationBody("ITEM_SOLD", "n-3"), "text/plain"); rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415, got %d", rec.Code)
+	}
+	large := bytes.Replace(notificationBody("ITEM_SOLD", "n-4"), []byte("test_user"), bytes.Repeat([]byte("x"), 1024), 1)
+	if rec := post(large, "application/json"); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", rec.Code)
+	}
+}
+
+func TestHandlersDoNotShareState(t *testing.T) {
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(context.Context, *pojo.Message) error { return nil }))
+	body := bytes.Replace(notificationBody("ITEM_SOLD", "n-1"), []byte(`"deprecated":false`), []byte(`"deprecated":true`), 1)
+
+	// two eBay environments publishing different keys under the same key id
+	for i := 0; i < 2; i++ {
+		key, _ := fakeebay.GenerateKey(testKid)
+		fake, err := fakeebay.StartTestServer(fakeebay.WithKey(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fake.Close()
+		logger, records := jsonLogger()
+		handler := sdk.Handler(fake.Config(), sdk.WithHTTPClient(fake.HTTP.Client()), sdk.WithPublicKeyEndpoint(fake.URL()+fakeebay.PublicKeyPath),
+			sdk.WithRegistry(registry), sdk.WithStructuredLogger(logger))
+
+		signature, _ := key.Sign(body)
+		notificationtest.AssertAccepted(t, notificationtest.Post(handler, body, signature))
+		if got := records(); len(got) == 0 || got[0]["level"] != "WARN" || !strings.Contains(got[0]["msg"].(string), "deprecated") {
+			t.Errorf("handler %d: expected its own deprecation warning, got %v", i, got)
+		}
+	}
+}
+
+func TestHandlersDoNotShareTokens(t *testing.T) {
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(context.Context, *pojo.Message) error { return nil }))
+	fake, err := fakeebay.StartTestServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+	body := notificationBody("ITEM_SOLD", "n-1")
+	signature, _ := fake.Key().Sign(body)
+
+	for i := 0; i < 2; i++ {
+		handler := sdk.Handler(fake.Config(), sdk.WithHTTPClient(fake.HTTP.Client()), sdk.WithPublicKeyEndpoint(fake.URL()+fakeebay.PublicKeyPath),
+			sdk.WithRegistry(registry), sdk.WithLogger(quietLogger))
+		notificationtest.AssertAccepted(t, notificationtest.Post(handler, body, signature))
+	}
+	if stats := fake.Stats(); stats.TokenRequests != 2 {
+		t.Errorf("expected each handler to request its own token, got %d requests", stats.TokenRequests)
+	}
+}
+
+func TestNewHandlerRejectsInvalidConfig(t *testing.T) {
+	if _, err := sdk.NewHandler(nil); !errors.Is(err, sdk.ErrInvalidConfig) {
+		t.Errorf("expected missing config to be rejected, got %v", err)
+	}
+	if _, err := sdk.NewHandler(handlerConfig(), sdk.WithEnvironment("STAGING")); !errors.Is(err, sdk.ErrInvalidConfig) {
+		t.Errorf("expected unknown environment to be rejected, got %v", err)
+	}
+	if handler, err := sdk.NewHandler(handlerConfig(), sdk.WithEnvironment("SANDBOX")); err != nil || handler == nil {
+		t.Errorf("expected handler, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected Handler to panic on an unknown environment")
+		}
+	}()
+	sdk.Handler(handlerConfig(), sdk.WithEnvironment("STAGING"))
+}
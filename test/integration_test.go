@@ -15,9 +15,11 @@
 package test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -70,11 +72,12 @@ func setUp() {
 		UserID:    "ma8vp1jySJC",
 		EiasToken: "nY+sHZ2PrBmdj6wVnY+sEZ2PrA2dj6wJnY+gAZGEpwmdj6x9nY+seQ=="}
 
+	data, _ := json.Marshal(payloadData)
 	notification = &pojo.Notification{NotificationID: "49feeaeb-4982-42d9-a377-9645b8479411_33f7e043-fed8-442b-9d44-791923bd9a6d",
 		EventDate:           "2021-03-19T20:43:59.462Z",
 		PublishDate:         "2021-03-19T20:43:59.679Z",
 		PublishAttemptCount: 1,
-		PayloadData:         *payloadData}
+		Data:                data}
 
 	message = &pojo.Message{Metadata: *metadata, Notification: *notification}
 
@@ -90,7 +93,8 @@ func tearDown() {
 }
 
 func loadTestData(key string) {
-	var testData map[string]interface{}
+	// raw messages keep the fields in the signed order
+	var testData map[string]map[string]json.RawMessage
 	// read file
 	data, err := ioutil.ReadFile("test.json")
 	if err != nil {
@@ -103,13 +107,10 @@ func loadTestData(key string) {
 		fmt.Println("Failed to unmarshall test file:", err)
 	}
 
-	currentData := testData[key].(map[string]interface{})
+	currentData := testData[key]
 
-	ds, _ := json.Marshal(currentData["message"])
-	json.Unmarshal(ds, &message)
-
-	ds, _ = json.Marshal(currentData["signature"])
-	json.Unmarshal(ds, &signature)
+	json.Unmarshal(currentData["message"], &message)
+	json.Unmarshal(currentData["signature"], &signature)
 }
 
 func loadConfigData(*pojo.Config) {
@@ -219,30 +220,41 @@ func TestValidateAndProcessConfigClientEnv(t *testing.T) {
 	}
 }
 
+//recordedKeyClient verifies the recorded notifications of test.json with the pinned production key
+//instead of fetching it from the notification API
+func recordedKeyClient(t *testing.T) *sdk.Client {
+	loadConfigData(Config)
+	var processed []string
+	client, err := sdk.NewClient(Config, recordedKeyOptions(t, &processed)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
 func TestValidateSignatureValidSuccess(t *testing.T) {
+	client := recordedKeyClient(t)
 	loadTestData("VALID")
-	loadConfigData(Config)
-	err, _ := sdk.ValidateAndProcess(message, signature, Config, "PRODUCTION")
-	if !strings.EqualFold(err, "") {
-		t.Errorf(`Failed to process`)
+	if _, err := client.ValidateAndProcess(context.Background(), message, signature); err != nil {
+		t.Errorf(`Failed to process: %v`, err)
 	}
 }
 
 func TestValidateSignatureInvalidSuccess(t *testing.T) {
+	client := recordedKeyClient(t)
 	loadTestData("INVALID")
-	loadConfigData(Config)
-	err, _ := sdk.ValidateAndProcess(message, signature, Config, "PRODUCTION")
-	if !strings.EqualFold(err, "412") {
-		t.Errorf(`Failed to process`)
+	_, err := client.ValidateAndProcess(context.Background(), message, signature)
+	if sdk.HTTPStatus(err) != http.StatusPreconditionFailed {
+		t.Errorf(`Failed to process: %v`, err)
 	}
 }
 
 func TestValidateSignatureMismatchSuccess(t *testing.T) {
+	client := recordedKeyClient(t)
 	loadTestData("SIGNATURE_MISMATCH")
-	loadConfigData(Config)
-	err, _ := sdk.ValidateAndProcess(message, signature, Config, "PRODUCTION")
-	if !strings.EqualFold(err, "412") {
-		t.Errorf(`Failed to process`)
+	_, err := client.ValidateAndProcess(context.Background(), message, signature)
+	if sdk.HTTPStatus(err) != http.StatusPreconditionFailed {
+		t.Errorf(`Failed to process: %v`, err)
 	}
 }
 
@@ -0,0 +1,212 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	service "github.com/ebay/event-notification-golang-sdk.git/lib/service"
+)
+
+//countingKeys counts lookups and optionally blocks them until release is closed
+type countingKeys struct {
+	service.KeyProvider
+	calls   int32
+	release chan struct{}
+}
+
+func (c *countingKeys) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	atomic.AddInt32(&c.calls, 1)
+	if c.release != nil {
+		<-c.release
+	}
+	return c.KeyProvider.Key(ctx, kid)
+}
+
+func newKeyCache(t *testing.T, size int, now *time.Time) *service.KeyCache {
+	cache, err := service.NewKeyCache(size,
+		service.WithKeyTTL(time.Hour),
+		service.WithNegativeTTL(time.Minute),
+		service.WithKeyCacheClock(func() time.Time { return *now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache
+}
+
+func TestKeyCacheTTL(t *testing.T) {
+	now := time.Now()
+	inner := &countingKeys{KeyProvider: testKeys(newTestKeys(t, testKid))}
+	cache := newKeyCache(t, 10, &now)
+	keys := cache.Provider(inner)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := keys.Key(ctx, testKid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if inner.calls != 1 {
+		t.Errorf("expected one lookup, got %d", inner.calls)
+	}
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 1 || stats.Size != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := keys.Key(ctx, testKid); err != nil {
+		t.Fatal(err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("expected expired key to be fetched again, got %d lookups", inner.calls)
+	}
+}
+
+func TestKeyCacheNegative(t *testing.T) {
+	now := time.Now()
+	inner := &countingKeys{KeyProvider: testKeys(newTestKeys(t, testKid))}
+	cache := newKeyCache(t, 10, &now)
+	keys := cache.Provider(inner)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := keys.Key(ctx, "unknown"); err == nil {
+			t.Fatal("expected unknown key to fail")
+		}
+	}
+	if inner.calls != 1 {
+		t.Errorf("expected failed lookup to be cached, got %d lookups", inner.calls)
+	}
+	if stats := cache.Stats(); stats.NegativeHits != 1 {
+		t.Errorf("expected one negative hit, got %+v", stats)
+	}
+
+	now = now.Add(time.Minute)
+	keys.Key(ctx, "unknown")
+	if inner.calls != 2 {
+		t.Errorf("expected failed lookup to expire, got %d lookups", inner.calls)
+	}
+}
+
+//flakyKeys fails every lookup with a transient error, or hangs until the context is done
+type flakyKeys struct {
+	calls int32
+	hang  bool
+}
+
+func (f *flakyKeys) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	atomic.AddInt32(&f.calls, 1)
+	if f.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return nil, errors.New("503 service unavailable")
+}
+
+func TestKeyCacheTransientFailures(t *testing.T) {
+	now := time.Now()
+	inner := &flakyKeys{}
+	keys := newKeyCache(t, 10, &now).Provider(inner)
+	for i := 0; i < 2; i++ {
+		if _, err := keys.Key(context.Background(), testKid); err == nil {
+			t.Fatal("expected transient failure")
+		}
+	}
+	if inner.calls != 2 {
+		t.Errorf("expected transient failures not to be cached, got %d lookups", inner.calls)
+	}
+
+	hung := &flakyKeys{hang: true}
+	cache, _ := service.NewKeyCache(10, service.WithFetchTimeout(10*time.Millisecond))
+	if _, err := cache.Provider(hung).Key(context.Background(), testKid); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected hung lookup to time out, got %v", err)
+	}
+	if _, err := cache.Provider(hung).Key(context.Background(), testKid); !errors.Is(err, context.DeadlineExceeded) || hung.calls != 2 {
+		t.Errorf("expected timed out lookup to be retried, got %v after %d lookups", err, hung.calls)
+	}
+}
+
+func TestKeyCacheChainTransientFailure(t *testing.T) {
+	now := time.Now()
+	flaky := &flakyKeys{}
+	chain := service.NewChainKeyProvider(testKeys(newTestKeys(t, "other")), flaky)
+	keys := newKeyCache(t, 10, &now).Provider(chain)
+	for i := 0; i < 2; i++ {
+		if _, err := keys.Key(context.Background(), testKid); err == nil || errors.Is(err, service.ErrKeyNotFound) {
+			t.Fatalf("expected a retryable failure, got %v", err)
+		}
+	}
+	if flaky.calls != 2 {
+		t.Errorf("expected the key id not to be negative cached, got %d lookups", flaky.calls)
+	}
+}
+
+func TestKeyCacheSingleFlight(t *testing.T) {
+	now := time.Now()
+	inner := &countingKeys{KeyProvider: testKeys(newTestKeys(t, testKid)), release: make(chan struct{})}
+	keys := newKeyCache(t, 10, &now).Provider(inner)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := keys.Key(context.Background(), testKid)
+			errs <- err
+		}()
+	}
+
+	// a waiter giving up does not cancel the shared lookup
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := keys.Key(ctx, testKid); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	close(inner.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+	if calls := atomic.LoadInt32(&inner.calls); calls != 1 {
+		t.Errorf("expected concurrent misses to share one lookup, got %d", calls)
+	}
+}
+
+func TestKeyCacheEviction(t *testing.T) {
+	now := time.Now()
+	cache := newKeyCache(t, 1, &now)
+	keys := cache.Provider(testKeys(newTestKeys(t, "first", "second")))
+
+	keys.Key(context.Background(), "first")
+	keys.Key(context.Background(), "second")
+	if stats := cache.Stats(); stats.Evictions != 1 || stats.Size != 1 {
+		t.Errorf("expected one eviction, got %+v", stats)
+	}
+
+	if _, err := service.NewKeyCache(0); err == nil {
+		t.Errorf("expected error for empty cache")
+	}
+}
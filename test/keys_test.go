@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	service "github.com/ebay/event-notification-golang-sdk.git/lib/service"
+)
+
+func TestStaticKeyProviderRecordedNotification(t *testing.T) {
+	keys := service.NewStaticKeyProvider(nil)
+	if err := keys.AddPEM(testKid, response.Key); err != nil {
+		t.Fatalf("failed to add recorded key: %v", err)
+	}
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithLogger(quietLogger))
+
+	loadTestData("VALID")
+	if _, err := client.ValidateAndProcess(context.Background(), message, signature); err != nil {
+		t.Errorf("recorded notification should verify with pinned key, got %v", err)
+	}
+	loadTestData("SIGNATURE_MISMATCH")
+	if _, err := client.ValidateAndProcess(context.Background(), message, signature); !errors.Is(err, sdk.ErrInvalidSignature) {
+		t.Errorf("expected invalid signature, got %v", err)
+	}
+	if _, err := keys.Key(context.Background(), "unknown"); !errors.Is(err, service.ErrKeyNotFound) {
+		t.Errorf("expected key not found, got %v", err)
+	}
+}
+
+func TestPEMDirKeyProvider(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	der, _ := x509.MarshalPKIXPublicKey(&keys[testKid].Private.PublicKey)
+	dir := t.TempDir()
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	if err := ioutil.WriteFile(filepath.Join(dir, testKid+".pem"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	provider := service.NewPEMDirKeyProvider(dir)
+
+	key, err := provider.Key(context.Background(), testKid)
+	if err != nil {
+		t.Fatalf("expected key from directory, got %v", err)
+	}
+	if !key.(*ecdsa.PublicKey).Equal(&keys[testKid].Private.PublicKey) {
+		t.Errorf("unexpected key read from directory")
+	}
+	for _, kid := range []string{"missing", "../" + testKid, ""} {
+		if _, err := provider.Key(context.Background(), kid); !errors.Is(err, service.ErrKeyNotFound) {
+			t.Errorf("%q: expected key not found, got %v", kid, err)
+		}
+	}
+}
+
+type algorithmKeys struct {
+	service.KeyProvider
+	alg, digest string
+}
+
+func (a algorithmKeys) KeyInfo(ctx context.Context, kid string) (*service.KeyInfo, error) {
+	key, err := a.Key(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	return &service.KeyInfo{Key: key, Algorithm: a.alg, Digest: a.digest}, nil
+}
+
+func TestChainKeyProvider(t *testing.T) {
+	first := newTestKeys(t, "first")
+	second := newTestKeys(t, "second")
+	chain := service.NewChainKeyProvider(
+		service.NewStaticKeyProvider(map[string]crypto.PublicKey{"first": &first["first"].Private.PublicKey}),
+		algorithmKeys{service.NewStaticKeyProvider(map[string]crypto.PublicKey{"second": &second["second"].Private.PublicKey}), "ECDSA", "SHA256"},
+	)
+	ctx := context.Background()
+
+	if key, err := chain.Key(ctx, "second"); err != nil || !key.(*ecdsa.PublicKey).Equal(&second["second"].Private.PublicKey) {
+		t.Errorf("expected key from second provider, got %v", err)
+	}
+	if info, _ := chain.KeyInfo(ctx, "second"); info.Algorithm != "ECDSA" || info.Digest != "SHA256" {
+		t.Errorf("expected algorithm of serving provider, got %s/%s", info.Algorithm, info.Digest)
+	}
+	if info, _ := chain.KeyInfo(ctx, "first"); info.Algorithm != "" {
+		t.Errorf("expected no algorithm from static provider, got %s", info.Algorithm)
+	}
+	if _, err := chain.Key(ctx, "third"); !errors.Is(err, service.ErrKeyNotFound) {
+		t.Errorf("expected key not found, got %v", err)
+	}
+
+	// the SHA1 signature disagrees with the SHA256 published for the key
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(chain), sdk.WithLogger(quietLogger))
+	body := notificationBody("MARKETPLACE_ACCOUNT_DELETION", "n-1")
+	if _, err := client.ValidateAndProcessRaw(ctx, body, second["second"].SignRaw(t, body)); !errors.Is(err, sdk.ErrInvalidSignature) {
+		t.Errorf("expected algorithm mismatch to be rejected, got %v", err)
+	}
+	if _, err := client.ValidateAndProcessRaw(ctx, body, first["first"].SignRaw(t, body)); err != nil {
+		t.Errorf("expected notification signed with first key to verify, got %v", err)
+	}
+}
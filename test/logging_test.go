@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	logging "github.com/ebay/event-notification-golang-sdk.git/lib/logging"
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	"github.com/ebay/event-notification-golang-sdk.git/lib/notification/notificationtest"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+)
+
+//Returns a debug level JSON logger and its decoded records
+func jsonLogger() (*slog.Logger, func() []map[string]interface{}) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return logger, func() []map[string]interface{} {
+		var records []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var record map[string]interface{}
+			if json.Unmarshal([]byte(line), &record) == nil {
+				records = append(records, record)
+			}
+		}
+		return records
+	}
+}
+
+func TestRedactingLogger(t *testing.T) {
+	logger, records := jsonLogger()
+	data := pojo.PayloadData{Username: "test_user", UserID: "ma8vp1jySJC", EiasToken: "nY+sHZ2PrBmdj6wVnY"}
+
+	logging.Redacting(logger, logging.Hash).Info("hashed", "data", logging.Payload(data), "UserID", "ma8vp1jySJC", slog.String(logging.KeyTopic, "T"))
+	logging.Redacting(logger, logging.Redact, "email").Warn("redacted", slog.Group("buyer", "email", "a@b.c", "username", "kept"))
+
+	got := records()
+	if len(got) != 2 {
+		t.Fatalf("expected 2 records, got %v", got)
+	}
+	hashed := got[0]["data"].(map[string]interface{})
+	if !strings.HasPrefix(hashed["username"].(string), "hmac:") || hashed["username"] == got[0]["UserID"] || got[0][logging.KeyTopic] != "T" {
+		t.Errorf("unexpected hashed record %v", got[0])
+	}
+	line, _ := json.Marshal(got[0])
+	for _, pii := range []string{data.Username, data.UserID, data.EiasToken} {
+		if strings.Contains(string(line), pii) {
+			t.Errorf("expected %s to be hashed, got %s", pii, line)
+		}
+	}
+	if buyer := got[1]["buyer"].(map[string]interface{}); buyer["email"] != logging.RedactedValue || buyer["username"] != "kept" {
+		t.Errorf("expected only the configured fields to be redacted, got %v", buyer)
+	}
+
+	var printed bytes.Buffer
+	printer := logging.FromPrinter(log.New(&printed, "", 0))
+	printer.Debug("dropped")
+	printer.Warn("Processor failed", logging.KeyAttempt, 2, "data", logging.Payload(data))
+	if want := "WARN Processor failed attempt=2 data.username=test_user"; !strings.HasPrefix(printed.String(), want) || strings.Contains(printed.String(), "dropped") {
+		t.Errorf("expected %q, got %q", want, printed.String())
+	}
+}
+
+func TestRedactingLoggerArrays(t *testing.T) {
+	logger, records := jsonLogger()
+	payload := map[string]interface{}{"buyers": []map[string]string{{"email": "a@b.c"}, {"email": "d@e.f"}}, "tags": []int{1, 2}}
+	logging.Redacting(logger, logging.Redact, "email").Info("redacted", "data", logging.Payload(payload))
+
+	got := records()
+	line, _ := json.Marshal(got)
+	if strings.Contains(string(line), "a@b.c") || strings.Contains(string(line), "d@e.f") {
+		t.Errorf("expected the emails in the array to be redacted, got %s", line)
+	}
+	data := got[0]["data"].(map[string]interface{})
+	if buyer := data["buyers"].(map[string]interface{})["1"].(map[string]interface{}); buyer["email"] != logging.RedactedValue {
+		t.Errorf("expected array elements keyed by index, got %v", data)
+	}
+	if tags := data["tags"].(map[string]interface{}); tags["0"] != float64(1) || tags["1"] != float64(2) {
+		t.Errorf("expected array values to be kept, got %v", tags)
+	}
+}
+
+func TestRedactingLoggerHashKey(t *testing.T) {
+	defer logging.SetHashKey(nil)
+	hash := func() string {
+		logger, records := jsonLogger()
+		logging.Redacting(logger, logging.Hash).Info("hashed", "userId", "ma8vp1jySJC")
+		return records()[0]["userId"].(string)
+	}
+	logging.SetHashKey([]byte("instance secret"))
+	first := hash()
+	if second := hash(); first != second {
+		t.Errorf("expected the same hash with the same key, got %s and %s", first, second)
+	}
+	logging.SetHashKey([]byte("other secret"))
+	if other := hash(); other == first {
+		t.Errorf("expected the hash to depend on the key, got %s", other)
+	}
+}
+
+func TestClientStructuredLogging(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error { return nil }))
+	registry.RegisterContext("ITEM_SHIPPED", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		return errors.New("database down")
+	}))
+	logger, records := jsonLogger()
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithStructuredLogger(logger),
+		sdk.WithRetry(sdk.RetryPolicy{MaxAttempts: 1}))
+	handler := client.Handler()
+
+	body := notificationBody("ITEM_SOLD", "n-1")
+	notificationtest.AssertAccepted(t, notificationtest.Post(handler, body, keys[testKid].SignRaw(t, body)))
+	body = notificationBody("ITEM_SHIPPED", "n-2")
+	notificationtest.AssertRetried(t, notificationtest.Post(handler, body, keys[testKid].SignRaw(t, body)))
+	notificationtest.AssertRejected(t, notificationtest.Post(handler, body, keys[testKid].SignRaw(t, notificationBody("ITEM_SHIPPED", "n-3"))))
+
+	want := []map[string]interface{}{
+		{"level": "DEBUG", "msg": "Handled notification", logging.KeyTopic: "ITEM_SOLD", logging.KeyNotificationID: "n-1", logging.KeyKid: testKid, logging.KeyOutcome: logging.OutcomeProcessed},
+		{"level": "WARN", "msg": "Processor failed", logging.KeyTopic: "ITEM_SHIPPED", logging.KeyNotificationID: "n-2", logging.KeyAttempt: 1.0, logging.KeyError: "database down"},
+		{"level": "ERROR", "msg": "Failed to handle notification", logging.KeyNotificationID: "n-2", logging.KeyKid: testKid, logging.KeyOutcome: logging.OutcomeFailed},
+		{"level": "WARN", "msg": "Rejected notification", logging.KeyKid: testKid, logging.KeyOutcome: logging.OutcomeRejected},
+	}
+	got := records()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d records, got %v", len(want), got)
+	}
+	for i, fields := range want {
+		for key, value := range fields {
+			if got[i][key] != value {
+				t.Errorf("record %d: expected %s=%v, got %v", i, key, value, got[i])
+			}
+		}
+	}
+}
+
+func TestAccountDeletionLogsHashedPII(t *testing.T) {
+	logger, records := jsonLogger()
+	logging.SetDefault(logger)
+	defer logging.SetDefault(nil)
+
+	var message pojo.Message
+	json.Unmarshal(notificationBody("MARKETPLACE_ACCOUNT_DELETION", "n-1"), &message)
+	processor.AccountDeletionMessageProcessor{}.Process(&message)
+
+	got := records()
+	line, _ := json.Marshal(got)
+	if len(got) != 1 || got[0][logging.KeyNotificationID] != "n-1" || strings.Contains(string(line), "test_user") || strings.Contains(string(line), "ma8vp1jySJC") {
+		t.Errorf("expected account deletion to be logged with hashed PII, got %s", line)
+	}
+}
+
+func TestAccountDeletionLogsWithClientLogger(t *testing.T) {
+	defaults, defaultRecords := jsonLogger()
+	logging.SetDefault(defaults)
+	defer logging.SetDefault(nil)
+
+	keys := newTestKeys(t, testKid)
+	logger, records := jsonLogger()
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithStructuredLogger(logger))
+	body := notificationBody("MARKETPLACE_ACCOUNT_DELETION", "n-1")
+	if _, err := client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body)); err != nil {
+		t.Fatal(err)
+	}
+
+	var processed bool
+	for _, record := range records() {
+		processed = processed || record["msg"] == "Processing account deletion"
+	}
+	if !processed || len(defaultRecords()) != 0 {
+		t.Errorf("expected the account deletion to be logged by the client logger only")
+	}
+
+	// a logger set on the processor takes precedence
+	own, ownRecords := jsonLogger()
+	var message pojo.Message
+	json.Unmarshal(body, &message)
+	processor.AccountDeletionMessageProcessor{Logger: own}.Process(&message)
+	got := ownRecords()
+	line, _ := json.Marshal(got)
+	if len(got) != 1 || strings.Contains(string(line), "test_user") {
+		t.Errorf("expected the account deletion to be logged with hashed PII, got %s", line)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	"github.com/ebay/event-notification-golang-sdk.git/lib/notification/notificationtest"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+)
+
+func TestNotificationTestToolkit(t *testing.T) {
+	key := notificationtest.GenerateKey(t, "kid-1")
+	keys := notificationtest.NewKeyProvider(key)
+	failing := false
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(context.Context, *pojo.Message) error {
+		if failing {
+			return errors.New("database unavailable")
+		}
+		return nil
+	}))
+	config := &pojo.Config{Endpoint: "https://example.com/webhook", VerificationToken: "71745723-d031-455c-bfa5-f90d11b4f20a"}
+	handler := sdk.Handler(config, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger))
+
+	body, signature := key.SignMessage(t, notificationtest.NewMessage("ITEM_SOLD", "n-1"))
+	notificationtest.AssertAccepted(t, notificationtest.Post(handler, body, signature))
+	if keys.Lookups() != 1 {
+		t.Errorf("expected one key lookup, got %d", keys.Lookups())
+	}
+
+	failing = true
+	notificationtest.AssertRetried(t, notificationtest.Post(handler, body, signature))
+	failing = false
+
+	notificationtest.AssertRejected(t, notificationtest.Post(handler, append(body, ' '), signature))
+	notificationtest.AssertRejected(t, notificationtest.Post(handler, body, key.SignRaw(t, body, notificationtest.WithDigest("SHA-256"))))
+	notificationtest.AssertRejected(t, notificationtest.Post(handler, body, key.SignRaw(t, body, notificationtest.WithAlg("RSA-PSS"))))
+	notificationtest.AssertStatus(t, notificationtest.Post(handler, body, key.SignRaw(t, body, notificationtest.WithKid("unknown"))), 500)
+
+	rotated := notificationtest.GenerateKey(t, "kid-2")
+	rotated.Digest = "SHA256"
+	keys.Add(rotated)
+	notificationtest.AssertAccepted(t, notificationtest.Post(handler, body, rotated.SignRaw(t, body)))
+	if _, err := key.Sign(body, notificationtest.WithDigest("MD5")); err == nil {
+		t.Error("expected unsupported digest to fail")
+	}
+
+	notificationtest.AssertChallengeResponse(t, notificationtest.Challenge(handler, "a8628072-3d33-45ee-9004-bee86830a22d"), "a8628072-3d33-45ee-9004-bee86830a22d", config)
+
+	fresh := sdk.Handler(config, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger), sdk.WithReplayProtection(sdk.DefaultFreshnessPolicy))
+	body, signature = key.SignMessage(t, notificationtest.NewMessage("ITEM_SOLD", "n-2"))
+	notificationtest.AssertAccepted(t, notificationtest.Post(fresh, body, signature))
+}
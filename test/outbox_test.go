@@ -0,0 +1,203 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	outbox "github.com/ebay/event-notification-golang-sdk.git/lib/outbox"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+	bolt "go.etcd.io/bbolt"
+)
+
+func outboxStores(t *testing.T) map[string]outbox.Store {
+	dir := t.TempDir()
+	journal, err := outbox.OpenFileStore(filepath.Join(dir, "outbox.journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { journal.Close() })
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "outbox.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	table := outbox.NewSQLStore(db)
+	if err := table.CreateTable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	boltDB, err := bolt.Open(filepath.Join(dir, "outbox.bolt"), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { boltDB.Close() })
+	bucket, err := outbox.NewBoltStore(boltDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]outbox.Store{"file": journal, "sql": table, "bolt": bucket}
+}
+
+func TestOutboxStores(t *testing.T) {
+	ctx := context.Background()
+	received := time.Date(2021, 3, 19, 20, 44, 0, 0, time.UTC)
+	for name, store := range outboxStores(t) {
+		for i, id := range []string{"c", "a", "b"} {
+			entry := &outbox.Entry{ID: id, Body: []byte(`{"id":"` + id + `"}`), Signature: "sig-" + id, ReceivedAt: received.Add(time.Duration(i) * time.Second)}
+			if err := store.Append(ctx, entry); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		if err := store.Ack(ctx, "a"); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		pending, err := store.Pending(ctx)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(pending) != 2 || pending[0].ID != "c" || pending[1].ID != "b" {
+			t.Fatalf("%s: expected c and b pending, got %v", name, pending)
+		}
+		if string(pending[1].Body) != `{"id":"b"}` || pending[1].Signature != "sig-b" || !pending[1].ReceivedAt.Equal(received.Add(2*time.Second)) {
+			t.Errorf("%s: unexpected entry %+v", name, pending[1])
+		}
+	}
+}
+
+func TestOutboxFileStoreReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.journal")
+	ctx := context.Background()
+	store, err := outbox.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Append(ctx, &outbox.Entry{ID: "a", Body: []byte("{}"), ReceivedAt: time.Now()})
+	store.Append(ctx, &outbox.Entry{ID: "b", Body: []byte("{}"), ReceivedAt: time.Now()})
+	store.Ack(ctx, "a")
+	store.Close()
+
+	// a record torn by a crash
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	file.WriteString(`{"op":"append","entry":{"id":"c","bo`)
+	file.Close()
+
+	store, err = outbox.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if pending, _ := store.Pending(ctx); len(pending) != 1 || pending[0].ID != "b" {
+		t.Errorf("expected b to survive the restart, got %v", pending)
+	}
+}
+
+func TestOutboxReplayAfterCrash(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	path := filepath.Join(t.TempDir(), "outbox.journal")
+	ctx := context.Background()
+
+	// the first instance acknowledges the notification and never processes it
+	store, _ := outbox.OpenFileStore(path)
+	crash := make(chan struct{})
+	defer close(crash)
+	stuck := processor.NewRegistry()
+	stuck.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		<-crash
+		return errors.New("crashed")
+	}))
+	crashed, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(stuck), sdk.WithLogger(quietLogger),
+		sdk.WithAsync(), sdk.WithOutbox(store))
+	body := notificationBody("ITEM_SOLD", "n-1")
+	if result, err := crashed.ValidateAndProcessRaw(ctx, body, keys[testKid].SignRaw(t, body)); err != nil || !result.Queued {
+		t.Fatalf("expected notification to be acknowledged, got %v", err)
+	}
+	store.Append(ctx, &outbox.Entry{ID: "tampered", Body: notificationBody("ITEM_SOLD", "n-2"), Signature: keys[testKid].SignRaw(t, body), ReceivedAt: time.Now()})
+	rotated := newTestKeys(t, "rotated")
+	store.Append(ctx, &outbox.Entry{ID: "rotated", Body: notificationBody("ITEM_SOLD", "n-3"), Signature: rotated["rotated"].SignRaw(t, body), ReceivedAt: time.Now()})
+	store.Append(ctx, &outbox.Entry{ID: "after", Body: notificationBody("ITEM_SOLD", "n-4"), Signature: keys[testKid].SignRaw(t, notificationBody("ITEM_SOLD", "n-4")), ReceivedAt: time.Now()})
+
+	// the next instance replays it
+	store, _ = outbox.OpenFileStore(path)
+	defer store.Close()
+	var processed []string
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		processed = append(processed, message.Notification.NotificationID)
+		return nil
+	}))
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger),
+		sdk.WithAsync(), sdk.WithOutbox(store))
+	replayed, err := client.Replay(ctx)
+	if !errors.Is(err, sdk.ErrKeyFetchFailed) || replayed != 2 || len(processed) != 2 || processed[0] != "n-1" || processed[1] != "n-4" {
+		t.Errorf("expected n-1 and n-4 to be replayed past the unknown key, got %d %v %v", replayed, processed, err)
+	}
+	if pending, _ := store.Pending(ctx); len(pending) != 1 || pending[0].ID != "rotated" {
+		t.Errorf("expected only the entry of the unknown key to be left, got %v", pending)
+	}
+}
+
+func TestOutboxReplaySkipsEntriesInFlight(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	ctx := context.Background()
+	started, release := make(chan struct{}), make(chan struct{})
+	failures := 1
+	calls := 0
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		calls++
+		if calls == 1 {
+			close(started)
+			<-release
+		}
+		if failures > 0 {
+			failures--
+			return errors.New("database unavailable")
+		}
+		return nil
+	}))
+	store, _ := outbox.OpenFileStore(filepath.Join(t.TempDir(), "outbox.journal"))
+	defer store.Close()
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger),
+		sdk.WithAsync(sdk.WithWorkers(1)), sdk.WithOutbox(store))
+	body := notificationBody("ITEM_SOLD", "n-1")
+	if _, err := client.ValidateAndProcessRaw(ctx, body, keys[testKid].SignRaw(t, body)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the worker still processes the entry
+	<-started
+	if replayed, err := client.Replay(ctx); err != nil || replayed != 0 {
+		t.Errorf("expected the entry being processed to be skipped, got %d %v", replayed, err)
+	}
+	close(release)
+	if err := client.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the failed entry is left for the next replay
+	if replayed, err := client.Replay(ctx); err != nil || replayed != 1 || calls != 2 {
+		t.Errorf("expected the failed entry to be replayed, got %d %v after %d calls", replayed, err, calls)
+	}
+}
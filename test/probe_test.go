@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	probe "github.com/ebay/event-notification-golang-sdk.git/lib/probe"
+)
+
+func TestProbeEndpoint(t *testing.T) {
+	registered := &pojo.Config{Endpoint: "https://example.com/webhook", VerificationToken: "71745723-d031-455c-bfa5-f90d11b4f20a"}
+	for name, test := range map[string]struct {
+		endpoint string
+		handler  http.Handler
+		problem  string
+	}{
+		"matching":       {registered.Endpoint, nil, ""},
+		"trailing slash": {registered.Endpoint + "/", nil, "trailing slash"},
+		"http":           {"http://example.com/webhook", nil, "http vs https"},
+		"token":          {registered.Endpoint, sdk.Handler(&pojo.Config{Endpoint: registered.Endpoint, VerificationToken: "other"}, sdk.WithLogger(quietLogger)), "check the verification token"},
+		"not json": {registered.Endpoint, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}), "not a JSON object"},
+		"wrong field": {registered.Endpoint, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"challenge_response":"abc"}`))
+		}), `no "challengeResponse" field`},
+	} {
+		handler := test.handler
+		if handler == nil {
+			handler = sdk.Handler(&pojo.Config{Endpoint: test.endpoint, VerificationToken: registered.VerificationToken}, sdk.WithLogger(quietLogger))
+		}
+		server := httptest.NewServer(handler)
+		result, err := probe.Endpoint(context.Background(), server.Client(), registered, server.URL+"/webhook")
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		if test.problem == "" {
+			if !result.OK() || result.Received != result.Expected || result.StatusCode != 200 || !strings.Contains(result.URL, "challenge_code="+result.ChallengeCode) {
+				t.Errorf("%s: expected matching response, got %+v", name, result)
+			}
+			continue
+		}
+		if result.OK() || !strings.Contains(strings.Join(result.Problems, "\n"), test.problem) {
+			t.Errorf("%s: expected problem %q, got %v", name, test.problem, result.Problems)
+		}
+	}
+
+	insecure := &pojo.Config{Endpoint: "http://example.com/webhook", VerificationToken: registered.VerificationToken}
+	server := httptest.NewServer(sdk.Handler(insecure, sdk.WithLogger(quietLogger)))
+	defer server.Close()
+	result, err := probe.Endpoint(context.Background(), server.Client(), insecure, server.URL)
+	if err != nil || result.Received != result.Expected || len(result.Problems) != 1 || !strings.Contains(result.Problems[0], "not an https URL") {
+		t.Errorf("expected an http endpoint to be flagged, got %+v %v", result, err)
+	}
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	errs "github.com/ebay/event-notification-golang-sdk.git/lib/errs"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+)
+
+type namedProcessor struct {
+	name string
+}
+
+func (n namedProcessor) Process(context.Context, *pojo.Message) error {
+	return nil
+}
+
+type legacyCounter struct {
+	calls *int
+}
+
+func (l legacyCounter) Process(*pojo.Message) {
+	*l.calls++
+}
+
+func lookupName(registry *processor.Registry, topic string) string {
+	p, ok := registry.Lookup(topic)
+	if !ok {
+		return ""
+	}
+	return p.(namedProcessor).name
+}
+
+func TestRegistryExactMatch(t *testing.T) {
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", namedProcessor{"sold"})
+	if name := lookupName(registry, "ITEM_SOLD"); name != "sold" {
+		t.Errorf("expected sold processor, got %q", name)
+	}
+	if _, ok := registry.Lookup("ITEM_LISTED"); ok {
+		t.Errorf("unregistered topic should not resolve")
+	}
+}
+
+func TestRegistryWildcardLongestPrefix(t *testing.T) {
+	registry := processor.NewRegistry()
+	registry.RegisterContext("*", namedProcessor{"all"})
+	registry.RegisterContext("MARKETPLACE_*", namedProcessor{"marketplace"})
+	registry.RegisterContext("MARKETPLACE_ACCOUNT_*", namedProcessor{"account"})
+	registry.RegisterContext("MARKETPLACE_ACCOUNT_DELETION", namedProcessor{"deletion"})
+
+	cases := map[string]string{
+		"MARKETPLACE_ACCOUNT_DELETION": "deletion",
+		"MARKETPLACE_ACCOUNT_CLOSURE":  "account",
+		"MARKETPLACE_ITEM":             "marketplace",
+		"ITEM_SOLD":                    "all",
+	}
+	for topic, want := range cases {
+		if name := lookupName(registry, topic); name != want {
+			t.Errorf("%s: expected %q, got %q", topic, want, name)
+		}
+	}
+}
+
+func TestRegistryUnregisterAndDefault(t *testing.T) {
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_*", namedProcessor{"item"})
+	registry.RegisterContext("ITEM_SOLD", namedProcessor{"sold"})
+	registry.SetDefaultContext(namedProcessor{"fallback"})
+
+	registry.Unregister("ITEM_SOLD")
+	if name := lookupName(registry, "ITEM_SOLD"); name != "item" {
+		t.Errorf("expected wildcard after unregister, got %q", name)
+	}
+	registry.Unregister("ITEM_*")
+	if name := lookupName(registry, "ITEM_SOLD"); name != "fallback" {
+		t.Errorf("expected fallback after unregister, got %q", name)
+	}
+	registry.SetDefault(nil)
+	if _, ok := registry.Lookup("ITEM_SOLD"); ok {
+		t.Errorf("topic should not resolve once default is cleared")
+	}
+}
+
+func TestRegistryRejectsNilProcessor(t *testing.T) {
+	registry := processor.NewRegistry()
+	var legacy *legacyCounter
+	var function processor.ProcessorFunc
+	for name, register := range map[string]func() error{
+		"Register":                    func() error { return registry.Register("ITEM_SOLD", nil) },
+		"Register typed nil":          func() error { return registry.Register("ITEM_SOLD", legacy) },
+		"RegisterContext":             func() error { return registry.RegisterContext("ITEM_*", nil) },
+		"RegisterContext typed nil":   func() error { return registry.RegisterContext("ITEM_*", function) },
+		"RegisterContext nil typed":   func() error { return registry.RegisterContext("ITEM_*", processor.Typed[pojo.PayloadData](nil)) },
+		"RegisterVersion":             func() error { return registry.RegisterVersion("ITEM_SOLD", "1.x", nil) },
+		"RegisterVersion typed nil":   func() error { return registry.RegisterVersion("ITEM_SOLD", "1.x", function) },
+		"RegisterVersion adapted nil": func() error { return registry.RegisterVersion("ITEM_SOLD", "1.x", processor.Adapt(legacy)) },
+	} {
+		if err := register(); !errors.Is(err, processor.ErrNilProcessor) {
+			t.Errorf("%s: expected nil processor to be rejected, got %v", name, err)
+		}
+	}
+	if _, ok := registry.Lookup("ITEM_SOLD"); ok {
+		t.Errorf("expected nothing to be registered")
+	}
+}
+
+func TestDefaultRegistryAccountDeletion(t *testing.T) {
+	p, err := processor.GetProcessor("MARKETPLACE_ACCOUNT_DELETION")
+	if err != nil {
+		t.Fatalf("account deletion processor should be registered by default: %v", err)
+	}
+	if _, isAccountDeletion := p.(processor.AccountDeletionMessageProcessor); !isAccountDeletion {
+		t.Errorf("unexpected default processor %T", p)
+	}
+}
+
+func TestRegistryLegacyProcessorAdapter(t *testing.T) {
+	calls := 0
+	registry := processor.NewRegistry()
+	registry.Register("ITEM_SOLD", legacyCounter{&calls})
+	p, ok := registry.Lookup("ITEM_SOLD")
+	if !ok {
+		t.Fatalf("legacy processor should be registered")
+	}
+	if err := p.Process(context.Background(), &pojo.Message{}); err != nil {
+		t.Errorf("legacy processor should never fail, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected legacy processor to be called once, got %d", calls)
+	}
+}
+
+func TestPermanentErrorClassification(t *testing.T) {
+	cause := errors.New("malformed payload")
+	err := processor.Permanent(cause)
+	if !processor.IsPermanent(err) {
+		t.Errorf("expected error to be permanent")
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("permanent error should wrap its cause")
+	}
+	if processor.IsPermanent(cause) {
+		t.Errorf("plain errors should be retryable")
+	}
+	if processor.Permanent(nil) != nil {
+		t.Errorf("Permanent(nil) should be nil")
+	}
+}
+
+func TestGetProcessorUnknownTopic(t *testing.T) {
+	if _, err := processor.GetProcessor("UNKNOWN_TOPIC"); !errors.Is(err, errs.ErrUnknownTopic) {
+		t.Errorf("expected unknown topic error, got %v", err)
+	}
+}
+
+func TestSafeProcessRecoversPanic(t *testing.T) {
+	panicking := processor.ProcessorFunc(func(context.Context, *pojo.Message) error {
+		var data map[string]string
+		data["boom"] = "nil map"
+		return nil
+	})
+	err := processor.SafeProcess(context.Background(), panicking, &pojo.Message{})
+	var panicErr *processor.PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected panic error, got %v", err)
+	}
+	if processor.IsPermanent(err) {
+		t.Errorf("panics should be retried")
+	}
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/ebay/event-notification-golang-sdk.git/adapters/gcf"
+	"github.com/ebay/event-notification-golang-sdk.git/adapters/lambda"
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+	service "github.com/ebay/event-notification-golang-sdk.git/lib/service"
+)
+
+//recordedKeyOptions verify the recorded notification of test.json and record the processed notifications
+func recordedKeyOptions(t *testing.T, processed *[]string) []sdk.Option {
+	keys := service.NewStaticKeyProvider(nil)
+	if err := keys.AddPEM(testKid, response.Key); err != nil {
+		t.Fatal(err)
+	}
+	registry := processor.NewRegistry()
+	registry.RegisterContext("MARKETPLACE_ACCOUNT_DELETION", processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+		*processed = append(*processed, message.Notification.NotificationID)
+		return nil
+	}))
+	return []sdk.Option{sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger)}
+}
+
+func loadEvent(t *testing.T, name string, event interface{}) {
+	data, err := ioutil.ReadFile(filepath.Join("events", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, event); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkChallenge(t *testing.T, name string, status int, body string) {
+	var challenge map[string]string
+	if status != http.StatusOK || json.Unmarshal([]byte(body), &challenge) != nil || challenge["challengeResponse"] == "" {
+		t.Errorf("%s: expected challenge response, got %d %s", name, status, body)
+	}
+}
+
+func TestLambdaAPIGateway(t *testing.T) {
+	var processed []string
+	handler := lambda.New(handlerConfig(), recordedKeyOptions(t, &processed)...)
+
+	var notification events.APIGatewayProxyRequest
+	loadEvent(t, "apigateway_notification.json", &notification)
+	resp, err := handler.APIGateway(context.Background(), notification)
+	if err != nil || resp.StatusCode != http.StatusNoContent || len(processed) != 1 {
+		t.Errorf("expected recorded notification to be processed, got %d %v", resp.StatusCode, err)
+	}
+
+	// single value headers only
+	notification.MultiValueHeaders = nil
+	notification.Headers["x-ebay-signature"] = "e30="
+	if resp, _ := handler.APIGateway(context.Background(), notification); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 for a bad signature, got %d", resp.StatusCode)
+	}
+
+	var challenge events.APIGatewayProxyRequest
+	loadEvent(t, "apigateway_challenge.json", &challenge)
+	resp, _ = handler.APIGateway(context.Background(), challenge)
+	checkChallenge(t, "api gateway", resp.StatusCode, resp.Body)
+	if resp.MultiValueHeaders["Content-Type"][0] != "application/json" {
+		t.Errorf("expected json content type, got %v", resp.MultiValueHeaders)
+	}
+}
+
+func TestLambdaAPIGatewayV2(t *testing.T) {
+	var processed []string
+	handler := lambda.New(handlerConfig(), recordedKeyOptions(t, &processed)...)
+
+	var notification events.APIGatewayV2HTTPRequest
+	loadEvent(t, "apigatewayv2_notification.json", &notification)
+	resp, err := handler.APIGatewayV2(context.Background(), notification)
+	if err != nil || resp.StatusCode != http.StatusNoContent || len(processed) != 1 {
+		t.Errorf("expected base64 encoded notification to be processed, got %d %v", resp.StatusCode, err)
+	}
+
+	notification.Body = "not base64!"
+	if resp, _ := handler.APIGatewayV2(context.Background(), notification); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed base64 body, got %d", resp.StatusCode)
+	}
+
+	var challenge events.APIGatewayV2HTTPRequest
+	loadEvent(t, "apigatewayv2_challenge.json", &challenge)
+	resp, _ = handler.APIGatewayV2(context.Background(), challenge)
+	checkChallenge(t, "http api", resp.StatusCode, resp.Body)
+}
+
+func TestLambdaALB(t *testing.T) {
+	var processed []string
+	handler := lambda.New(handlerConfig(), recordedKeyOptions(t, &processed)...)
+
+	var notification events.ALBTargetGroupRequest
+	loadEvent(t, "alb_notification.json", &notification)
+	resp, err := handler.ALB(context.Background(), notification)
+	if err != nil || resp.StatusCode != http.StatusNoContent || resp.StatusDescription != "204 No Content" || len(processed) != 1 {
+		t.Errorf("expected recorded notification to be processed, got %d %v", resp.StatusCode, err)
+	}
+	if resp.Headers != nil {
+		t.Errorf("expected multi-value headers in the response, got %v", resp.Headers)
+	}
+
+	var challenge events.ALBTargetGroupRequest
+	loadEvent(t, "alb_challenge.json", &challenge)
+	resp, _ = handler.ALB(context.Background(), challenge)
+	checkChallenge(t, "alb", resp.StatusCode, resp.Body)
+	if resp.Headers["Content-Type"] != "application/json" || resp.MultiValueHeaders != nil {
+		t.Errorf("expected single value headers in the response, got %v", resp.Headers)
+	}
+
+	// the percent encoded challenge code of the event is decoded
+	var expected events.APIGatewayProxyRequest
+	loadEvent(t, "apigateway_challenge.json", &expected)
+	reference, _ := lambda.New(handlerConfig()).APIGateway(context.Background(), expected)
+	if resp.Body != reference.Body {
+		t.Errorf("expected %s, got %s", reference.Body, resp.Body)
+	}
+}
+
+func TestCloudFunction(t *testing.T) {
+	var processed []string
+	function := gcf.Function(handlerConfig(), recordedKeyOptions(t, &processed)...)
+
+	var event events.APIGatewayProxyRequest
+	loadEvent(t, "apigateway_notification.json", &event)
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(event.Body))
+	req.Header.Set("x-ebay-signature", event.Headers["x-ebay-signature"])
+	req.Header.Set("content-type", "application/json")
+	rec := httptest.NewRecorder()
+	function(rec, req)
+	if rec.Code != http.StatusNoContent || len(processed) != 1 {
+		t.Errorf("expected recorded notification to be processed, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	function(rec, httptest.NewRequest(http.MethodGet, "/webhook?challenge_code=a8628072-3d33-45ee-9004-bee86830a22d", nil))
+	checkChallenge(t, "cloud function", rec.Code, rec.Body.String())
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"testing"
+
+	"github.com/ebay/event-notification-golang-sdk.git/lib/notification/notificationtest"
+	service "github.com/ebay/event-notification-golang-sdk.git/lib/service"
+)
+
+const testKid = "9936261a-7d7b-4621-a0f1-96ccb428af49"
+
+//testKeys is a key provider backed by generated ECDSA keys
+type testKeys map[string]*notificationtest.KeyPair
+
+func newTestKeys(t *testing.T, kids ...string) testKeys {
+	keys := testKeys{}
+	for _, kid := range kids {
+		keys[kid] = notificationtest.GenerateKey(t, kid)
+	}
+	return keys
+}
+
+func (k testKeys) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	key, ok := k[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", service.ErrKeyNotFound, kid)
+	}
+	return key.Public(), nil
+}
+
+func notificationBody(topic string, notificationID string) []byte {
+	return []byte(`{"metadata":{"topic":"` + topic + `","schemaVersion":"1.0","deprecated":false},` +
+		`"notification":{"notificationId":"` + notificationID + `","eventDate":"2021-03-19T20:43:59.462Z",` +
+		`"publishDate":"2021-03-19T20:43:59.679Z","publishAttemptCount":1,` +
+		`"data":{"username":"test_user","userId":"ma8vp1jySJC","eiasToken":"nY+sHZ2PrBmdj6wVnY+sEZ2PrA2dj6wJnY+gAZGEpwmdj6x9nY+seQ=="}}}`)
+}
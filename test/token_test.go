@@ -0,0 +1,198 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	service "github.com/ebay/event-notification-golang-sdk.git/lib/service"
+)
+
+//identityServer issues numbered tokens valid for 7200 seconds
+func identityServer(t *testing.T, delay time.Duration) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/identity/v1/oauth2/token" || r.Method != http.MethodPost {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		n := atomic.AddInt32(&calls, 1)
+		time.Sleep(delay)
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":7200,"token_type":"Application Access Token"}`, n)
+	}))
+	return server, &calls
+}
+
+func tokenEnvironment(server *httptest.Server) *pojo.CustomEnvironment {
+	return &pojo.CustomEnvironment{
+		BaseURL:      strings.TrimPrefix(server.URL, "https://"),
+		ClientID:     "clientId",
+		ClientSecret: "clientSecret",
+		Environment:  "PRODUCTION",
+	}
+}
+
+func TestTokenSourceCachesUntilRefreshWindow(t *testing.T) {
+	server, calls := identityServer(t, 0)
+	defer server.Close()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		now = now.Add(d)
+		mu.Unlock()
+	}
+	tokens := service.NewTokenSource(tokenEnvironment(server), server.Client(), service.WithTokenClock(clock))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if token, err := tokens.Token(ctx); err != nil || token != "token-1" {
+			t.Fatalf("expected cached token-1, got %q %v", token, err)
+		}
+	}
+	if atomic.LoadInt32(calls) != 1 {
+		t.Errorf("expected one identity call, got %d", atomic.LoadInt32(calls))
+	}
+
+	// inside the refresh window the current token is served while a new one is fetched
+	advance(7200*time.Second - service.DefaultRefreshBefore + time.Second)
+	if token, _ := tokens.Token(ctx); token != "token-1" {
+		t.Errorf("expected still valid token-1 during refresh, got %q", token)
+	}
+	token := ""
+	for deadline := time.Now().Add(2 * time.Second); token != "token-2" && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+		token, _ = tokens.Token(ctx)
+	}
+	if token != "token-2" {
+		t.Errorf("expected refreshed token-2, got %q", token)
+	}
+
+	// an expired token is never served
+	advance(3 * time.Hour)
+	if token, _ := tokens.Token(ctx); token != "token-3" {
+		t.Errorf("expected token-3 after expiry, got %q", token)
+	}
+}
+
+func TestTokenSourceSingleFlight(t *testing.T) {
+	server, calls := identityServer(t, 50*time.Millisecond)
+	defer server.Close()
+	tokens := service.NewTokenSource(tokenEnvironment(server), server.Client())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if token, err := tokens.Token(context.Background()); err != nil || token != "token-1" {
+				t.Errorf("expected shared token-1, got %q %v", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if atomic.LoadInt32(calls) != 1 {
+		t.Errorf("expected concurrent callers to share one identity call, got %d", atomic.LoadInt32(calls))
+	}
+}
+
+func TestTokenSourceOAuthError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":"invalid_client","error_description":"client authentication failed"}`)
+	}))
+	defer server.Close()
+	tokens := service.NewTokenSource(tokenEnvironment(server), server.Client())
+
+	_, err := tokens.Token(context.Background())
+	var oauthErr *service.OAuthError
+	if !errors.As(err, &oauthErr) {
+		t.Fatalf("expected OAuthError, got %v", err)
+	}
+	if oauthErr.StatusCode != 401 || oauthErr.Code != "invalid_client" || oauthErr.Description != "client authentication failed" {
+		t.Errorf("unexpected OAuthError %+v", oauthErr)
+	}
+}
+
+func TestSharedTokenSourcePerEnvironment(t *testing.T) {
+	sandbox := &pojo.CustomEnvironment{BaseURL: "api.sandbox.ebay.com", ClientID: "id", ClientSecret: "secret", Environment: "SANDBOX"}
+	production := &pojo.CustomEnvironment{BaseURL: "api.ebay.com", ClientID: "id", ClientSecret: "secret", Environment: "PRODUCTION"}
+	if service.SharedTokenSource(sandbox, nil) != service.SharedTokenSource(sandbox, nil) {
+		t.Errorf("same environment should share a token source")
+	}
+	if service.SharedTokenSource(sandbox, nil) == service.SharedTokenSource(production, nil) {
+		t.Errorf("environments should not share a token source")
+	}
+}
+
+func TestTokenSourceLifetime(t *testing.T) {
+	var calls int32
+	expiresIn := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":%d}`, n, expiresIn)
+	}))
+	defer server.Close()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	tokens := service.NewTokenSource(tokenEnvironment(server), server.Client(), service.WithTokenClock(func() time.Time { return now }))
+	ctx := context.Background()
+
+	var oauthErr *service.OAuthError
+	if _, err := tokens.Token(ctx); !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_response" {
+		t.Errorf("expected token without lifetime to be rejected, got %v", err)
+	}
+
+	// a lifetime shorter than the refresh window is served for half of it
+	expiresIn = 60
+	for i := 0; i < 3; i++ {
+		if token, err := tokens.Token(ctx); err != nil || token != "token-2" {
+			t.Fatalf("expected cached token-2, got %q %v", token, err)
+		}
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Errorf("expected short lived token to be cached, got %d identity calls", atomic.LoadInt32(&calls))
+	}
+}
+
+func TestTokenSourceTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	tokens := service.NewTokenSource(tokenEnvironment(server), server.Client(), service.WithTokenTimeout(20*time.Millisecond))
+
+	if _, err := tokens.Token(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected hung identity call to time out, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+)
+
+type itemSold struct {
+	ItemID string  `json:"itemId"`
+	Price  float64 `json:"price"`
+}
+
+func itemSoldBody(data string) []byte {
+	return []byte(`{"metadata":{"topic":"ITEM_SOLD","schemaVersion":"1.0","deprecated":false},` +
+		`"notification":{"notificationId":"n-1","eventDate":"2021-03-19T20:43:59.462Z",` +
+		`"publishDate":"2021-03-19T20:43:59.679Z","publishAttemptCount":1,"data":` + data + `}}`)
+}
+
+func TestTypedProcessor(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	var received []*processor.Message[itemSold]
+	registry := processor.NewRegistry()
+	registry.RegisterContext("ITEM_SOLD", processor.Typed[itemSold](processor.TypedProcessorFunc[itemSold](func(ctx context.Context, message *processor.Message[itemSold]) error {
+		received = append(received, message)
+		return nil
+	})))
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger))
+
+	body := itemSoldBody(`{"itemId":"v1|110","price":12.5,"buyer":{"username":"b"}}`)
+	result, err := client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body))
+	if err != nil || result.Dropped {
+		t.Fatalf("expected typed notification to be processed, got %+v %v", result, err)
+	}
+	if len(received) != 1 || received[0].Data != (itemSold{ItemID: "v1|110", Price: 12.5}) || received[0].Notification.NotificationID != "n-1" {
+		t.Errorf("unexpected typed message %+v", received)
+	}
+
+	for name, data := range map[string]string{"mismatch": `{"itemId":"v1|110","price":"free"}`, "missing": `null`} {
+		body = itemSoldBody(data)
+		result, err = client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body))
+		var payloadErr *processor.PayloadError
+		if err != nil || !result.Dropped || !errors.Is(result.Err, processor.ErrPayloadMismatch) || !errors.As(result.Err, &payloadErr) {
+			t.Fatalf("%s: expected payload mismatch to be dropped, got %+v %v", name, result, err)
+		}
+		if payloadErr.Topic != "ITEM_SOLD" || payloadErr.SchemaVersion != "1.0" || payloadErr.Type != "test.itemSold" {
+			t.Errorf("%s: unexpected payload error %+v", name, payloadErr)
+		}
+	}
+}
+
+func TestNotificationDataKeptRaw(t *testing.T) {
+	body := itemSoldBody(`{"price":12.5,"itemId":"v1|110","extra":[1,2]}`)
+	var message pojo.Message
+	if err := json.Unmarshal(body, &message); err != nil {
+		t.Fatal(err)
+	}
+	marshalled, _ := json.Marshal(&message)
+	if string(marshalled) != string(body) {
+		t.Errorf("expected data of other topics to survive decoding, got %s", marshalled)
+	}
+
+	var data pojo.PayloadData
+	if err := processor.DecodeData(&message, &data); err != nil || data.Username != "" {
+		t.Errorf("expected foreign fields to be ignored, got %+v %v", data, err)
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+
+	helper "github.com/ebay/event-notification-golang-sdk.git/lib/helper"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+)
+
+var verifierBody = []byte(`{"metadata":{"topic":"ITEM_SOLD"},"notification":{"data":{"note":"<b>&</b>"}}}`)
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	hasher := hash.New()
+	hasher.Write(data)
+	return hasher.Sum(nil)
+}
+
+func TestVerifyAlgorithms(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	edPublic, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+
+	cases := []struct {
+		alg, digest string
+		key         crypto.PublicKey
+		sign        func() []byte
+	}{
+		{"ecdsa", "SHA1", &ecKey.PublicKey, func() []byte {
+			sig, _ := ecdsa.SignASN1(rand.Reader, ecKey, digest(crypto.SHA1, verifierBody))
+			return sig
+		}},
+		{"ECDSA", "SHA-256", &ecKey.PublicKey, func() []byte {
+			sig, _ := ecdsa.SignASN1(rand.Reader, ecKey, digest(crypto.SHA256, verifierBody))
+			return sig
+		}},
+		{"ecdsa", "sha384", &ecKey.PublicKey, func() []byte {
+			sig, _ := ecdsa.SignASN1(rand.Reader, ecKey, digest(crypto.SHA384, verifierBody))
+			return sig
+		}},
+		{"Ed25519", "", edPublic, func() []byte {
+			return ed25519.Sign(edPrivate, verifierBody)
+		}},
+		{"RSASSA-PSS", "SHA256", &rsaKey.PublicKey, func() []byte {
+			sig, _ := rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA256, digest(crypto.SHA256, verifierBody), nil)
+			return sig
+		}},
+	}
+	for _, c := range cases {
+		algorithm := helper.Algorithm{Alg: c.alg, Digest: c.digest}
+		sig := c.sign()
+		if err := helper.Verify(algorithm, c.key, verifierBody, sig); err != nil {
+			t.Errorf("%s: expected valid signature, got %v", algorithm, err)
+		}
+		tampered := append([]byte{}, verifierBody...)
+		tampered[len(tampered)-3] = 'x'
+		if err := helper.Verify(algorithm, c.key, tampered, sig); !errors.Is(err, helper.ErrVerification) {
+			t.Errorf("%s: expected verification failure, got %v", algorithm, err)
+		}
+	}
+}
+
+func TestVerifyKeyTypeMismatch(t *testing.T) {
+	edPublic, _, _ := ed25519.GenerateKey(rand.Reader)
+	err := helper.Verify(helper.Algorithm{Alg: "ecdsa", Digest: "SHA1"}, edPublic, verifierBody, []byte("sig"))
+	if !errors.Is(err, helper.ErrKeyType) {
+		t.Errorf("expected key type error, got %v", err)
+	}
+}
+
+func TestVerifyUnsupportedAlgorithm(t *testing.T) {
+	err := helper.Verify(helper.Algorithm{Alg: "ecdsa", Digest: "MD5"}, nil, verifierBody, nil)
+	if !errors.Is(err, helper.ErrUnsupportedAlgorithm) {
+		t.Errorf("expected unsupported algorithm, got %v", err)
+	}
+}
+
+func TestVerifyAllowlistPreventsDowngrade(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	sig, _ := ecdsa.SignASN1(rand.Reader, ecKey, digest(crypto.SHA1, verifierBody))
+
+	defer helper.SetAllowedAlgorithms(helper.AllowedAlgorithms()...)
+	helper.SetAllowedAlgorithms(helper.Algorithm{Alg: "ECDSA", Digest: "SHA256"})
+
+	err := helper.Verify(helper.Algorithm{Alg: "ecdsa", Digest: "SHA1"}, &ecKey.PublicKey, verifierBody, sig)
+	if !errors.Is(err, helper.ErrAlgorithmNotAllowed) {
+		t.Errorf("expected SHA1 to be rejected, got %v", err)
+	}
+}
+
+func TestResolveAlgorithmCrossCheck(t *testing.T) {
+	header := &pojo.XeBaySignatureHeader{Alg: "ecdsa", Digest: "SHA1"}
+	algorithm, err := helper.ResolveAlgorithm(header, &pojo.Response{Algorithm: "ECDSA", Digest: "SHA1"})
+	if err != nil || algorithm != (helper.Algorithm{Alg: "ECDSA", Digest: "SHA1"}) {
+		t.Errorf("expected ECDSA/SHA1, got %v %v", algorithm, err)
+	}
+
+	header = &pojo.XeBaySignatureHeader{Alg: "ecdsa", Digest: "SHA256"}
+	if _, err = helper.ResolveAlgorithm(header, &pojo.Response{Algorithm: "ECDSA", Digest: "SHA1"}); !errors.Is(err, helper.ErrAlgorithmMismatch) {
+		t.Errorf("expected digest mismatch, got %v", err)
+	}
+
+	header = &pojo.XeBaySignatureHeader{}
+	algorithm, err = helper.ResolveAlgorithm(header, &pojo.Response{Algorithm: "ECDSA", Digest: "SHA1"})
+	if err != nil || algorithm.Digest != "SHA1" {
+		t.Errorf("expected algorithm from public key, got %v %v", algorithm, err)
+	}
+}
+
+func TestVerifyRecordedNotification(t *testing.T) {
+	loadTestData("VALID")
+	decoded, _ := base64.StdEncoding.DecodeString(signature)
+	var header pojo.XeBaySignatureHeader
+	json.Unmarshal(decoded, &header)
+
+	key := strings.Replace(response.Key, "-----BEGIN PUBLIC KEY-----", "-----BEGIN PUBLIC KEY-----\n", 1)
+	key = strings.Replace(key, "-----END PUBLIC KEY-----", "\n-----END PUBLIC KEY-----", 1)
+	block, _ := pem.Decode([]byte(key))
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse recorded key: %v", err)
+	}
+
+	algorithm, err := helper.ResolveAlgorithm(&header, response)
+	if err != nil {
+		t.Fatalf("unexpected algorithm error: %v", err)
+	}
+	sig, _ := base64.StdEncoding.DecodeString(header.Signature)
+	body, _ := json.Marshal(message)
+	if err := helper.Verify(algorithm, publicKey, body, sig); err != nil {
+		t.Errorf("recorded notification should verify, got %v", err)
+	}
+}
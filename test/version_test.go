@@ -0,0 +1,219 @@
+/*
+ * Copyright (c) 2022 eBay Inc.
+ *
+ *  Licensed under the Apache License, Version 2.0 (the "License");
+ *  you may not use this file except in compliance with the License.
+ *  You may obtain a copy of the License at
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	sdk "github.com/ebay/event-notification-golang-sdk.git/lib/notification"
+	pojo "github.com/ebay/event-notification-golang-sdk.git/lib/pojo"
+	processor "github.com/ebay/event-notification-golang-sdk.git/lib/processor"
+)
+
+func versionedBody(version string, deprecated bool, data string) []byte {
+	body := strings.Replace(string(itemSoldBody(data)), `"schemaVersion":"1.0","deprecated":false`, `"schemaVersion":"`+version+`","deprecated":false`, 1)
+	if deprecated {
+		body = strings.Replace(body, `"deprecated":false`, `"deprecated":true`, 1)
+	}
+	return []byte(body)
+}
+
+func TestVersionRange(t *testing.T) {
+	cases := []struct {
+		versions string
+		in, out  []string
+	}{
+		{"1.x", []string{"1", "1.0", "1.5", "1.5.2"}, []string{"2.0", "", "10.0", "1.a"}},
+		{"1.2", []string{"1.2", "1.2.0"}, []string{"1.2.1", "1.3", "1"}},
+		{"1.2.x", []string{"1.2", "1.2.7"}, []string{"1.3"}},
+		{"x", []string{"", "1.0", "beta"}, nil},
+	}
+	for _, c := range cases {
+		r, err := processor.ParseVersionRange(c.versions)
+		if err != nil {
+			t.Fatalf("%s: %v", c.versions, err)
+		}
+		for _, version := range c.in {
+			if !r.Contains(version) {
+				t.Errorf("expected %s to contain %q", c.versions, version)
+			}
+		}
+		for _, version := range c.out {
+			if r.Contains(version) {
+				t.Errorf("expected %s not to contain %q", c.versions, version)
+			}
+		}
+	}
+	for _, versions := range []string{"", "1.x.2", "v1", "1.-1"} {
+		if _, err := processor.ParseVersionRange(versions); err == nil {
+			t.Errorf("expected %q to be rejected", versions)
+		}
+	}
+}
+
+func TestRegistryVersionRouting(t *testing.T) {
+	registry := processor.NewRegistry()
+	var got string
+	named := func(name string) processor.ContextProcessor {
+		return processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error {
+			got = name
+			return nil
+		})
+	}
+	registry.RegisterContext("ITEM_SOLD", named("any"))
+	registry.RegisterVersion("ITEM_SOLD", "1.x", named("v1"))
+	registry.RegisterVersion("ITEM_SOLD", "1.2", named("v1.2"))
+	registry.RegisterVersion("ITEM_*", "2.x", named("items v2"))
+	if err := registry.RegisterVersion("ITEM_SOLD", "one", named("bad")); err == nil {
+		t.Errorf("expected malformed version range to be rejected")
+	}
+
+	for version, want := range map[string]string{"1.0": "v1", "1.2": "v1.2", "1.3.1": "v1", "2.0": "any", "": "any"} {
+		p, ok := registry.LookupVersion("ITEM_SOLD", version)
+		if !ok {
+			t.Fatalf("%s: expected a processor", version)
+		}
+		p.Process(context.Background(), nil)
+		if got != want {
+			t.Errorf("%s: expected %s processor, got %s", version, want, got)
+		}
+	}
+	if _, ok := registry.LookupVersion("ITEM_SHIPPED", "1.0"); ok {
+		t.Errorf("expected no processor for version outside the wildcard range")
+	}
+	if p, ok := registry.LookupVersion("ITEM_SHIPPED", "2.1"); !ok || p.Process(context.Background(), nil) != nil || got != "items v2" {
+		t.Errorf("expected wildcard versioned processor, got %s", got)
+	}
+
+	// ranges are compared once parsed
+	registry.RegisterVersion("ITEM_SOLD", " 1.* ", named("v1 again"))
+	if p, _ := registry.LookupVersion("ITEM_SOLD", "1.0"); p.Process(context.Background(), nil) != nil || got != "v1 again" {
+		t.Errorf("expected 1.* to replace 1.x, got %s", got)
+	}
+	if err := registry.RegisterVersion("ITEM_SOLD", "1.2.0", named("v1.2.0")); err == nil {
+		t.Errorf("expected 1.2.0 to be rejected as overlapping 1.2")
+	}
+	registry.RegisterVersion("ITEM_SOLD", "1", named("v1.0"))
+	registry.RegisterVersion("ITEM_SOLD", "1.0.x", named("v1.0.x"))
+	if p, _ := registry.LookupVersion("ITEM_SOLD", "1.0"); p.Process(context.Background(), nil) != nil || got != "v1.0" {
+		t.Errorf("expected exact range to win over 1.0.x, got %s", got)
+	}
+
+	registry.UnregisterVersion("ITEM_SOLD", "1.2 ")
+	if p, _ := registry.LookupVersion("ITEM_SOLD", "1.2"); p.Process(context.Background(), nil) != nil || got != "v1 again" {
+		t.Errorf("expected unregistered range to fall back to 1.x, got %s", got)
+	}
+	registry.Unregister("ITEM_SOLD")
+	if _, ok := registry.LookupVersion("ITEM_SOLD", "1.0"); ok {
+		t.Errorf("expected Unregister to remove the versioned processors")
+	}
+}
+
+type itemSoldV1 struct {
+	Item  string `json:"item"`
+	Cents int    `json:"cents"`
+}
+
+func TestUpcasting(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	registry := processor.NewRegistry()
+	var received []*processor.Message[itemSold]
+	registry.RegisterVersion("ITEM_SOLD", "2.x", processor.Typed[itemSold](processor.TypedProcessorFunc[itemSold](func(ctx context.Context, message *processor.Message[itemSold]) error {
+		received = append(received, message)
+		return nil
+	})))
+	registry.RegisterUpcaster("ITEM_SOLD", "1.x", "1.9", processor.UpcastFunc(func(v itemSoldV1) (itemSoldV1, error) {
+		if v.Item == "" {
+			return v, errors.New("missing item")
+		}
+		return v, nil
+	}))
+	registry.RegisterUpcaster("ITEM_SOLD", "1.9", "2.0", processor.UpcastFunc(func(v itemSoldV1) (itemSold, error) {
+		return itemSold{ItemID: v.Item, Price: float64(v.Cents) / 100}, nil
+	}))
+	if err := registry.RegisterUpcaster("ITEM_SOLD", "1.9.0", "2.0", processor.UpcastFunc(func(v itemSoldV1) (itemSold, error) {
+		return itemSold{ItemID: v.Item}, nil
+	})); err == nil || !strings.Contains(err.Error(), "overlaps") {
+		t.Errorf("expected upcaster range overlapping 1.9 to be rejected, got %v", err)
+	}
+	if err := registry.RegisterUpcaster("ITEM_SOLD", "3.x", "4.0", nil); err == nil {
+		t.Errorf("expected nil upcaster to be rejected")
+	}
+	if err := registry.RegisterUpcaster("ITEM_SOLD", "1.x", "two", nil); err == nil {
+		t.Errorf("expected malformed target version to be rejected")
+	}
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(quietLogger))
+	ctx := context.Background()
+
+	body := versionedBody("1.0", false, `{"item":"v1|110","cents":1250}`)
+	if _, err := client.ValidateAndProcessRaw(ctx, body, keys[testKid].SignRaw(t, body)); err != nil {
+		t.Fatalf("expected upcast notification to be processed, got %v", err)
+	}
+	body = versionedBody("2.0", false, `{"itemId":"v1|111","price":3}`)
+	if _, err := client.ValidateAndProcessRaw(ctx, body, keys[testKid].SignRaw(t, body)); err != nil {
+		t.Fatalf("expected current notification to be processed, got %v", err)
+	}
+	if len(received) != 2 || received[0].Data != (itemSold{ItemID: "v1|110", Price: 12.5}) || received[0].Metadata.SchemaVersion != "2.0" || received[1].Data.ItemID != "v1|111" {
+		t.Fatalf("unexpected upcast messages %+v", received)
+	}
+
+	body = versionedBody("1.0", false, `{"cents":1}`)
+	result, err := client.ValidateAndProcessRaw(ctx, body, keys[testKid].SignRaw(t, body))
+	var upcastErr *processor.UpcastError
+	if err != nil || !result.Dropped || !errors.As(result.Err, &upcastErr) || upcastErr.From != "1.0" || upcastErr.To != "1.9" {
+		t.Errorf("expected failing upcaster to drop the notification, got %+v %v", result, err)
+	}
+	body = versionedBody("3.0", false, `{}`)
+	if _, err := client.ValidateAndProcessRaw(ctx, body, keys[testKid].SignRaw(t, body)); !errors.Is(err, sdk.ErrUnknownTopic) {
+		t.Errorf("expected unsupported version to be an unknown topic, got %v", err)
+	}
+}
+
+func TestDeprecatedSchemaVersion(t *testing.T) {
+	keys := newTestKeys(t, testKid)
+	registry := processor.NewRegistry()
+	registry.SetDefaultContext(processor.ProcessorFunc(func(ctx context.Context, message *pojo.Message) error { return nil }))
+	var logs bytes.Buffer
+	var hooked []string
+	client, _ := sdk.NewClient(&pojo.Config{}, sdk.WithKeyProvider(keys), sdk.WithRegistry(registry), sdk.WithLogger(log.New(&logs, "", 0)),
+		sdk.WithDeprecationHook(func(ctx context.Context, message *pojo.Message) {
+			hooked = append(hooked, message.Metadata.SchemaVersion)
+		}))
+
+	for _, version := range []string{"1.0", "1.0", "1.1"} {
+		body := versionedBody(version, true, `{}`)
+		if _, err := client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	body := versionedBody("2.0", false, `{}`)
+	client.ValidateAndProcessRaw(context.Background(), body, keys[testKid].SignRaw(t, body))
+
+	if len(hooked) != 3 {
+		t.Errorf("expected hook for each deprecated notification, got %v", hooked)
+	}
+	deprecations := client.Deprecations()
+	if len(deprecations) != 2 || deprecations[0].SchemaVersion != "1.0" || deprecations[0].Count != 2 || deprecations[1].Count != 1 || deprecations[0].FirstSeen.IsZero() {
+		t.Errorf("unexpected deprecations %+v", deprecations)
+	}
+	if warnings := strings.Count(logs.String(), "deprecated schema version"); warnings != 2 {
+		t.Errorf("expected one warning per topic and version, got %d", warnings)
+	}
+}